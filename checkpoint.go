@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Progress identifies a point within a drawing by the index of a path and
+// the distance travelled along it.
+type Progress struct {
+	PathIndex int     `json:"pathIndex"`
+	Distance  float64 `json:"distance"`
+}
+
+// CheckpointStore persists a drawing and the progress of plotting it to a
+// directory, so that a plot interrupted by a crash or power loss can be resumed.
+type CheckpointStore struct {
+	dir string
+}
+
+func NewCheckpointStore(dir string) *CheckpointStore {
+	return &CheckpointStore{dir: dir}
+}
+
+// DefaultCheckpointDir returns the directory that checkpoints are kept in unless otherwise specified.
+func DefaultCheckpointDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "axigo")
+}
+
+type savedPath struct {
+	PenUp  bool         `json:"penUp"`
+	Points [][2]float64 `json:"points"`
+}
+
+func (s *CheckpointStore) drawingFile() string  { return filepath.Join(s.dir, "drawing.json") }
+func (s *CheckpointStore) progressFile() string { return filepath.Join(s.dir, "progress.json") }
+
+// Exists returns true if there is an unfinished plot in the store.
+func (s *CheckpointStore) Exists() bool {
+	_, err := os.Stat(s.progressFile())
+	return err == nil
+}
+
+// SaveDrawing stores the drawing being plotted and resets its progress.
+func (s *CheckpointStore) SaveDrawing(d Drawing) error {
+	paths := make([]savedPath, 0, len(d.paths))
+	for _, path := range d.paths {
+		points := make([][2]float64, 0, len(path.Path))
+		for _, point := range path.Path {
+			points = append(points, [2]float64{point.x, point.y})
+		}
+		paths = append(paths, savedPath{path.penUp, points})
+	}
+	if err := s.write(s.drawingFile(), paths); err != nil {
+		return err
+	}
+	return s.SaveProgress(Progress{})
+}
+
+func (s *CheckpointStore) SaveProgress(progress Progress) error {
+	return s.write(s.progressFile(), progress)
+}
+
+// Load returns the stored drawing and how far along it the plot got.
+func (s *CheckpointStore) Load() (Drawing, Progress, error) {
+	var paths []savedPath
+	var progress Progress
+	if err := s.read(s.drawingFile(), &paths); err != nil {
+		return Drawing{}, Progress{}, err
+	}
+	if err := s.read(s.progressFile(), &progress); err != nil {
+		return Drawing{}, Progress{}, err
+	}
+	d := Drawing{}
+	for _, path := range paths {
+		points := make(Path, 0, len(path.Points))
+		for _, point := range path.Points {
+			points = append(points, Vec2d{point[0], point[1]})
+		}
+		d.paths = append(d.paths, PenPath{points, path.PenUp})
+	}
+	if progress.PathIndex < 0 || progress.PathIndex > len(d.paths) {
+		return Drawing{}, Progress{}, errors.New("checkpoint progress is outside of the drawing")
+	}
+	return d, progress, nil
+}
+
+// Clear removes the stored plot, once it's finished or no longer wanted.
+func (s *CheckpointStore) Clear() error {
+	for _, name := range []string{s.progressFile(), s.drawingFile()} {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// write atomically replaces the named file with the JSON encoding of v,
+// so that a crash mid-write never leaves a truncated checkpoint behind.
+func (s *CheckpointStore) write(name string, v interface{}) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func (s *CheckpointStore) read(name string, v interface{}) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("no checkpoint found")
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// after returns the part of the path that lies beyond the given distance from its start.
+func (p Path) after(distance float64) Path {
+	var travelled float64
+	for i := 1; i < len(p); i++ {
+		length := p[i-1].Distance(p[i])
+		if travelled+length > distance {
+			start := p[i-1].LinearInterpolate(p[i], distance-travelled)
+			return append(Path{start}, p[i:]...)
+		}
+		travelled += length
+	}
+	if len(p) == 0 {
+		return p
+	}
+	return Path{p[len(p)-1]}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errInjected = errors.New("write: broken pipe")
+
+// failingSimulator is a Simulator whose failAt'th move fails, as if the
+// connection to the device had dropped.
+type failingSimulator struct {
+	*Simulator
+	moves  int
+	failAt int
+}
+
+func (f *failingSimulator) fail() error {
+	f.moves++
+	if f.moves == f.failAt {
+		return errInjected
+	}
+	return nil
+}
+
+func (f *failingSimulator) Move(stepsX, stepsY int, duration time.Duration) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.Simulator.Move(stepsX, stepsY, duration)
+}
+
+func (f *failingSimulator) LowLevelMove(motor1, motor2 MotorMove) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.Simulator.LowLevelMove(motor1, motor2)
+}
+
+func TestCheckpointResumeAfterError(t *testing.T) {
+	d := controlDrawing()
+	whole := NewSimulator()
+	if err := PlotDrawing(whole, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewCheckpointStore(t.TempDir())
+	opts := PlotOptions{Checkpoints: store}
+	sim := &failingSimulator{Simulator: NewSimulator(), failAt: whole.moves / 2}
+	if err := PlotDrawing(sim, d, opts); !errors.Is(err, errInjected) {
+		t.Fatalf("got %v, want the injected error", err)
+	}
+	if !sim.penUp {
+		t.Error("the pen was left down after the error")
+	}
+	// the checkpoint is kept, with how far the plot got
+	if !store.Exists() {
+		t.Fatal("the checkpoint was cleared after the error")
+	}
+	saved, progress, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved, d) {
+		t.Error("the checkpoint holds a different drawing")
+	}
+	if progress.PathIndex <= 0 || progress.PathIndex >= len(d.paths) {
+		t.Errorf("the checkpoint is at path %d of %d, want it part way through", progress.PathIndex, len(d.paths))
+	}
+
+	// once the connection is back, the plot carries on where it left off
+	sim.failAt = 0
+	movesBefore := sim.Simulator.moves
+	if err := ResumeDrawing(sim, opts); err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists() {
+		t.Error("the checkpoint wasn't cleared once the plot finished")
+	}
+	if sim.x != whole.x || sim.y != whole.y || !sim.penUp {
+		t.Errorf("the resumed plot ended at (%d, %d) steps, want (%d, %d) with the pen up", sim.x, sim.y, whole.x, whole.y)
+	}
+	if resumed := sim.Simulator.moves - movesBefore; resumed >= whole.moves {
+		t.Errorf("resuming made %d moves, as many as the whole plot's %d", resumed, whole.moves)
+	}
+	// between them, the two runs drew every square
+	var drawn []Vec2d
+	for _, path := range sim.paths {
+		if !path.penUp {
+			for _, p := range path.Path {
+				drawn = append(drawn, p.Multiply(1.0/simRenderScale))
+			}
+		}
+	}
+	for _, path := range d.penDownPaths() {
+		for _, corner := range path {
+			if !hasPointNear(drawn, corner, 0.001) {
+				t.Errorf("%v wasn't drawn", corner)
+			}
+		}
+	}
+	if len(sim.violations) != 0 {
+		t.Errorf("got violations: %v", sim.violations)
+	}
+}
+
+func TestCheckpointStore(t *testing.T) {
+	store := NewCheckpointStore(t.TempDir())
+	if store.Exists() {
+		t.Error("an empty store has a checkpoint")
+	}
+	if _, _, err := store.Load(); err == nil {
+		t.Error("expected an error loading from an empty store")
+	}
+	d := newDrawing([]Path{{{1, 1}, {2, 1.5}}, {{3, 3}, {4, 4}, {3, 4}}})
+	if err := store.SaveDrawing(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveProgress(Progress{PathIndex: 3, Distance: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, progress, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, d) || progress != (Progress{3, 0.5}) {
+		t.Errorf("loaded %v at %+v, want %v at path 3 and 0.5in", loaded, progress, d)
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists() {
+		t.Error("the checkpoint is still there after clearing it")
+	}
+}
+
+func TestPathAfter(t *testing.T) {
+	path := Path{{0, 0}, {2, 0}, {2, 2}}
+	tests := []struct {
+		distance float64
+		want     Path
+	}{
+		{0, Path{{0, 0}, {2, 0}, {2, 2}}},
+		{1, Path{{1, 0}, {2, 0}, {2, 2}}},
+		{3, Path{{2, 1}, {2, 2}}},
+		{5, Path{{2, 2}}},
+	}
+	for _, test := range tests {
+		if got := path.after(test.distance); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("after(%v) = %v, want %v", test.distance, got, test.want)
+		}
+	}
+}
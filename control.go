@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPlotAborted is returned by PlotDrawing when the plot was aborted through its PlotControl.
+var ErrPlotAborted = errors.New("plot aborted")
+
+// PlotControl lets a plot in progress be paused, resumed or aborted from
+// another goroutine. The plotter only acts on it at safe points between
+// moves: an abort stops the plot at the next move, and a pause once the
+// carriage has come to a stop, at the end of the path it's on at the latest.
+type PlotControl struct {
+	mu      sync.Mutex
+	active  bool
+	paused  bool
+	aborted bool
+
+	// changed is closed and replaced whenever the state changes, so that a
+	// paused plotter can wait for a resume or abort.
+	changed chan struct{}
+}
+
+func NewPlotControl() *PlotControl {
+	return &PlotControl{changed: make(chan struct{})}
+}
+
+// begin resets the control at the start of a plot.
+func (c *PlotControl) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active, c.paused, c.aborted = true, false, false
+}
+
+// end marks the plot as finished.
+func (c *PlotControl) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active = false
+}
+
+// Active returns true while a plot is in progress.
+func (c *PlotControl) Active() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active
+}
+
+func (c *PlotControl) Pause() {
+	c.update(func() { c.paused = true })
+}
+
+func (c *PlotControl) Resume() {
+	c.update(func() { c.paused = false })
+}
+
+// Toggle pauses a running plot or resumes a paused one.
+func (c *PlotControl) Toggle() {
+	c.update(func() { c.paused = !c.paused })
+}
+
+func (c *PlotControl) Abort() {
+	c.update(func() { c.aborted = true })
+}
+
+func (c *PlotControl) update(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.active {
+		return
+	}
+	f()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// state returns the current state along with a channel that's closed on the next change.
+func (c *PlotControl) state() (paused, aborted bool, changed <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused, c.aborted, c.changed
+}
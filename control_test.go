@@ -0,0 +1,183 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// hookedSimulator is a Simulator that's safe to inspect while a plot is
+// running, and that calls hook just before its hookAt'th move.
+type hookedSimulator struct {
+	mu  sync.Mutex
+	sim *Simulator
+
+	moves  int
+	hookAt int
+	hook   func()
+}
+
+func newHookedSimulator(hookAt int, hook func()) *hookedSimulator {
+	return &hookedSimulator{sim: NewSimulator(), hookAt: hookAt, hook: hook}
+}
+
+func (h *hookedSimulator) moved() {
+	h.mu.Lock()
+	h.moves++
+	call := h.moves == h.hookAt && h.hook != nil
+	h.mu.Unlock()
+	if call {
+		h.hook()
+	}
+}
+
+func (h *hookedSimulator) locked(f func() error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return f()
+}
+
+func (h *hookedSimulator) SteppersOn() error  { return h.locked(h.sim.SteppersOn) }
+func (h *hookedSimulator) SteppersOff() error { return h.locked(h.sim.SteppersOff) }
+func (h *hookedSimulator) PenUp() error       { return h.locked(h.sim.PenUp) }
+func (h *hookedSimulator) PenDown() error     { return h.locked(h.sim.PenDown) }
+func (h *hookedSimulator) Home() error        { return h.locked(h.sim.Home) }
+
+func (h *hookedSimulator) Move(stepsX, stepsY int, duration time.Duration) error {
+	h.moved()
+	return h.locked(func() error { return h.sim.Move(stepsX, stepsY, duration) })
+}
+
+func (h *hookedSimulator) LowLevelMove(motor1, motor2 MotorMove) error {
+	h.moved()
+	return h.locked(func() error { return h.sim.LowLevelMove(motor1, motor2) })
+}
+
+func (h *hookedSimulator) Version() (string, error)              { return h.sim.Version() }
+func (h *hookedSimulator) QueryButton() (bool, error)            { return false, nil }
+func (h *hookedSimulator) QueryMotion() (MotionStatus, error)    { return MotionStatus{}, nil }
+func (h *hookedSimulator) Raw(command ...string) (string, error) { return h.sim.Raw(command...) }
+
+// state returns the number of moves so far and whether the pen is up.
+func (h *hookedSimulator) state() (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.moves, h.sim.penUp
+}
+
+// waitForPause waits until no moves have been made for a while, which is
+// once the plot has come to a stop.
+func (h *hookedSimulator) waitForPause(t *testing.T) {
+	moves, _ := h.state()
+	for i := 0; i < 100; i++ {
+		time.Sleep(20 * time.Millisecond)
+		now, _ := h.state()
+		if now == moves {
+			return
+		}
+		moves = now
+	}
+	t.Fatal("the plot didn't pause")
+}
+
+// controlDrawing is a grid of squares, which takes hundreds of moves to
+// plot, many more than the plotter can queue up ahead of the device.
+func controlDrawing() Drawing {
+	var paths []Path
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 8; col++ {
+			paths = append(paths, square(0.5+float64(col), 0.5+float64(row), 0.5))
+		}
+	}
+	return newDrawing(paths)
+}
+
+// controlHookAt is the move at which the tests pause or abort the plot.
+const controlHookAt = 10
+
+func TestPlotAbort(t *testing.T) {
+	ctl := NewPlotControl()
+	h := newHookedSimulator(controlHookAt, ctl.Abort)
+	err := PlotDrawing(h, controlDrawing(), PlotOptions{Control: ctl})
+	if err != ErrPlotAborted {
+		t.Fatalf("got %v, want ErrPlotAborted", err)
+	}
+	// the carriage is parked where the plot started, with the pen up
+	if h.sim.x != 0 || h.sim.y != 0 || !h.sim.penUp {
+		t.Errorf("the aborted plot ended at (%d, %d) steps with the pen up %v, want (0, 0) with it up", h.sim.x, h.sim.y, h.sim.penUp)
+	}
+	whole := NewSimulator()
+	if err := PlotDrawing(whole, controlDrawing(), PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if h.moves >= whole.moves {
+		t.Errorf("the aborted plot made %d moves, as many as the whole drawing's %d", h.moves, whole.moves)
+	}
+	if ctl.Active() {
+		t.Error("the control is still active after the plot")
+	}
+}
+
+func TestPlotPauseAndResume(t *testing.T) {
+	whole := NewSimulator()
+	if err := PlotDrawing(whole, controlDrawing(), PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctl := NewPlotControl()
+	h := newHookedSimulator(controlHookAt, ctl.Pause)
+	done := make(chan error)
+	go func() { done <- PlotDrawing(h, controlDrawing(), PlotOptions{Control: ctl}) }()
+
+	h.waitForPause(t)
+	select {
+	case err := <-done:
+		t.Fatalf("the plot finished with %v instead of pausing", err)
+	default:
+	}
+	if _, penUp := h.state(); !penUp {
+		t.Error("the pen is down while the plot is paused")
+	}
+	ctl.Resume()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// the plot carries on to the end, lifting the pen once more for the
+	// pause unless it was already up
+	if h.sim.x != whole.x || h.sim.y != whole.y || !h.sim.penUp {
+		t.Errorf("the resumed plot ended at (%d, %d) steps, want (%d, %d) with the pen up", h.sim.x, h.sim.y, whole.x, whole.y)
+	}
+	if lifts := h.sim.penLifts; lifts != whole.penLifts && lifts != whole.penLifts+1 {
+		t.Errorf("the resumed plot lifted the pen %d times, want %d or one more", lifts, whole.penLifts)
+	}
+	if len(h.sim.violations) != 0 {
+		t.Errorf("the resumed plot had violations: %v", h.sim.violations)
+	}
+}
+
+func TestPlotAbortWhilePaused(t *testing.T) {
+	ctl := NewPlotControl()
+	h := newHookedSimulator(controlHookAt, ctl.Pause)
+	done := make(chan error)
+	go func() { done <- PlotDrawing(h, controlDrawing(), PlotOptions{Control: ctl}) }()
+
+	h.waitForPause(t)
+	ctl.Abort()
+	if err := <-done; err != ErrPlotAborted {
+		t.Fatalf("got %v, want ErrPlotAborted", err)
+	}
+	if h.sim.x != 0 || h.sim.y != 0 || !h.sim.penUp {
+		t.Errorf("the aborted plot ended at (%d, %d) steps with the pen up %v, want (0, 0) with it up", h.sim.x, h.sim.y, h.sim.penUp)
+	}
+}
+
+func TestPlotControlOutsidePlot(t *testing.T) {
+	// pausing or aborting when there's no plot does nothing to the next one
+	ctl := NewPlotControl()
+	ctl.Pause()
+	ctl.Abort()
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, controlDrawing(), PlotOptions{Control: ctl}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import "math"
+
+const (
+	// DefaultCurveTolerance is how far, in inches, a flattened curve may stray from the true curve.
+	DefaultCurveTolerance = 0.001
+
+	// flattenMinDepth and flattenMaxDepth bound how many times a curve is
+	// split in half when it's flattened. The minimum makes sure that curves
+	// that happen to pass back through their chord, like closed arcs and
+	// S-shaped Béziers, are still split up.
+	flattenMinDepth = 2
+	flattenMaxDepth = 16
+)
+
+// Curve is a parametric curve, running from At(0) to At(1).
+type Curve interface {
+	At(t float64) Vec2d
+}
+
+// QuadraticBezier is a Bézier curve from P0 to P2 with a single control point.
+type QuadraticBezier struct {
+	P0, P1, P2 Vec2d
+}
+
+func (b QuadraticBezier) At(t float64) Vec2d {
+	u := 1 - t
+	return b.P0.Multiply(u * u).Add(b.P1.Multiply(2 * u * t)).Add(b.P2.Multiply(t * t))
+}
+
+// Flatten returns the curve as a path that's within tolerance of it.
+func (b QuadraticBezier) Flatten(tolerance float64) Path {
+	return Flatten(b, tolerance)
+}
+
+// CubicBezier is a Bézier curve from P0 to P3 with two control points.
+type CubicBezier struct {
+	P0, P1, P2, P3 Vec2d
+}
+
+func (b CubicBezier) At(t float64) Vec2d {
+	u := 1 - t
+	return b.P0.Multiply(u * u * u).
+		Add(b.P1.Multiply(3 * u * u * t)).
+		Add(b.P2.Multiply(3 * u * t * t)).
+		Add(b.P3.Multiply(t * t * t))
+}
+
+// Flatten returns the curve as a path that's within tolerance of it.
+func (b CubicBezier) Flatten(tolerance float64) Path {
+	return Flatten(b, tolerance)
+}
+
+// Arc is part of an ellipse with radii Radius.x and Radius.y, rotated by
+// Rotation about its center. It starts at the angle Start and turns through
+// Sweep, with positive angles turning from the x axis towards the y axis.
+// All angles are in radians.
+type Arc struct {
+	Center   Vec2d
+	Radius   Vec2d
+	Rotation float64
+	Start    float64
+	Sweep    float64
+}
+
+// NewArc returns a circular arc.
+func NewArc(center Vec2d, radius, start, sweep float64) Arc {
+	return Arc{Center: center, Radius: Vec2d{radius, radius}, Start: start, Sweep: sweep}
+}
+
+// Circle returns a full circle, starting and ending on the right of the center.
+func Circle(center Vec2d, radius float64) Arc {
+	return NewArc(center, radius, 0, 2*math.Pi)
+}
+
+func (a Arc) At(t float64) Vec2d {
+	angle := a.Start + t*a.Sweep
+	x, y := a.Radius.x*math.Cos(angle), a.Radius.y*math.Sin(angle)
+	sin, cos := math.Sincos(a.Rotation)
+	return Vec2d{a.Center.x + x*cos - y*sin, a.Center.y + x*sin + y*cos}
+}
+
+// Flatten returns the arc as a path that's within tolerance of it.
+func (a Arc) Flatten(tolerance float64) Path {
+	return Flatten(a, tolerance)
+}
+
+// Flatten returns a path that follows the curve to within tolerance. The
+// curve is split in half for as long as any of the points at a quarter, half
+// and three quarters of the way along a piece are too far from its chord,
+// so that points are only spent where the curve actually bends. A tolerance
+// that isn't positive could never be met, so DefaultCurveTolerance is used
+// instead.
+func Flatten(c Curve, tolerance float64) Path {
+	if tolerance <= 0 {
+		tolerance = DefaultCurveTolerance
+	}
+	start, end := c.At(0), c.At(1)
+	out := Path{start}
+	var subdivide func(t0, t1 float64, p0, p1 Vec2d, depth int)
+	subdivide = func(t0, t1 float64, p0, p1 Vec2d, depth int) {
+		tm := (t0 + t1) / 2
+		pm := c.At(tm)
+		if depth >= flattenMaxDepth || depth >= flattenMinDepth &&
+			pm.SegmentDistance(p0, p1) <= tolerance &&
+			c.At((t0+tm)/2).SegmentDistance(p0, p1) <= tolerance &&
+			c.At((tm+t1)/2).SegmentDistance(p0, p1) <= tolerance {
+			out = append(out, p1)
+			return
+		}
+		subdivide(t0, tm, p0, pm, depth+1)
+		subdivide(tm, t1, pm, p1, depth+1)
+	}
+	subdivide(0, 1, start, end, 0)
+	return out
+}
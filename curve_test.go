@@ -0,0 +1,125 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// distanceToPath returns how far the point is from the nearest segment of the path.
+func distanceToPath(p Vec2d, path Path) float64 {
+	distance := math.Inf(1)
+	for i := 1; i < len(path); i++ {
+		distance = math.Min(distance, p.SegmentDistance(path[i-1], path[i]))
+	}
+	return distance
+}
+
+func TestFlatten(t *testing.T) {
+	curves := map[string]Curve{
+		"circle":         Circle(Vec2d{2, 2}, 1),
+		"arc":            NewArc(Vec2d{0, 0}, 3, math.Pi/4, -math.Pi),
+		"ellipse":        Arc{Center: Vec2d{1, 1}, Radius: Vec2d{2, 0.5}, Rotation: 0.3, Sweep: 2 * math.Pi},
+		"quadratic":      QuadraticBezier{Vec2d{0, 0}, Vec2d{1, 2}, Vec2d{2, 0}},
+		"cubic":          CubicBezier{Vec2d{0, 0}, Vec2d{0, 1}, Vec2d{1, 1}, Vec2d{1, 0}},
+		"s-shaped cubic": CubicBezier{Vec2d{0, 0}, Vec2d{1, 1}, Vec2d{0, 1}, Vec2d{1, 0}},
+	}
+	for name, c := range curves {
+		for _, tolerance := range []float64{0.01, 0.001} {
+			path := Flatten(c, tolerance)
+			if path[0] != c.At(0) || path[len(path)-1] != c.At(1) {
+				t.Errorf("%s: flattened from %v to %v, want %v to %v", name, path[0], path[len(path)-1], c.At(0), c.At(1))
+			}
+			for i := 0; i <= 1000; i++ {
+				p := c.At(float64(i) / 1000)
+				if d := distanceToPath(p, path); d > tolerance {
+					t.Errorf("%s: %v is %v from the path flattened to %v", name, p, d, tolerance)
+					break
+				}
+			}
+		}
+		if coarse, fine := Flatten(c, 0.01), Flatten(c, 0.001); len(fine) <= len(coarse) {
+			t.Errorf("%s: a finer tolerance gave %d points, and a coarser one %d", name, len(fine), len(coarse))
+		}
+	}
+}
+
+func TestFlattenTolerance(t *testing.T) {
+	circle := Circle(Vec2d{0, 0}, 1)
+	want := len(circle.Flatten(DefaultCurveTolerance))
+	for _, tolerance := range []float64{0, -1} {
+		if got := len(circle.Flatten(tolerance)); got != want {
+			t.Errorf("a tolerance of %v gave %d points, want %d", tolerance, got, want)
+		}
+	}
+	// a straight curve is split no more than the minimum depth
+	line := QuadraticBezier{Vec2d{0, 0}, Vec2d{1, 1}, Vec2d{2, 2}}
+	if got := len(line.Flatten(0.001)); got != 1<<flattenMinDepth+1 {
+		t.Errorf("a straight curve flattened to %d points", got)
+	}
+}
+
+func TestCurvePath(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		start, end Vec2d
+	}{
+		{"circle", []string{"2", "2", "1"}, Vec2d{3, 2}, Vec2d{3, 2}},
+		{"arc", []string{"0", "0", "1", "0", "90"}, Vec2d{1, 0}, Vec2d{0, 1}},
+		{"curve", []string{"0", "0", "1", "1", "2", "0"}, Vec2d{0, 0}, Vec2d{2, 0}},
+		{"curve", []string{"0", "0", "1", "1", "2", "1", "3", "0"}, Vec2d{0, 0}, Vec2d{3, 0}},
+	}
+	for _, test := range tests {
+		path, err := curvePath(test.name, test.args, 0.001)
+		if err != nil {
+			t.Errorf("%s %v: %v", test.name, test.args, err)
+			continue
+		}
+		if path[0].Distance(test.start) > 1e-9 || path[len(path)-1].Distance(test.end) > 1e-9 {
+			t.Errorf("%s %v: runs from %v to %v, want %v to %v", test.name, test.args, path[0], path[len(path)-1], test.start, test.end)
+		}
+	}
+
+	// the tolerance is passed through to the curve
+	coarse, _ := curvePath("circle", []string{"0", "0", "1"}, 0.01)
+	fine, _ := curvePath("circle", []string{"0", "0", "1"}, 0.0001)
+	if len(fine) <= len(coarse) {
+		t.Errorf("a finer tolerance gave %d points, and a coarser one %d", len(fine), len(coarse))
+	}
+
+	for _, args := range [][]string{{"circle", "1", "2"}, {"curve", "1", "2", "3", "4"}, {"arc", "1", "x", "1", "0", "90"}, {"spiral", "1", "1", "1"}} {
+		if _, err := curvePath(args[0], args[1:], 0.001); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestPlotCurve(t *testing.T) {
+	circle := Circle(Vec2d{2, 2}, 1).Flatten(DefaultCurveTolerance)
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, newDrawing([]Path{circle}), PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	report := sim.Report()
+	if len(report.Violations) > 0 {
+		t.Errorf("plotting a circle broke the limits: %v", report.Violations)
+	}
+	// the circle is drawn all the way round, back to within a step of where it started
+	if x, y := sim.x, sim.y; math.Abs(float64(x-3*stepsPerInch)) > 1 || math.Abs(float64(y-2*stepsPerInch)) > 1 {
+		t.Errorf("the circle ended at (%d, %d)", x, y)
+	}
+	var drawn []Vec2d
+	for _, path := range sim.paths {
+		if !path.penUp {
+			for _, p := range path.Path {
+				drawn = append(drawn, p.Multiply(1.0/simRenderScale))
+			}
+		}
+	}
+	for _, angle := range []float64{0, math.Pi / 2, math.Pi, 3 * math.Pi / 2} {
+		p := Vec2d{2 + math.Cos(angle), 2 + math.Sin(angle)}
+		if !hasPointNear(drawn, p, 0.01) {
+			t.Errorf("the circle doesn't pass through %v", p)
+		}
+	}
+}
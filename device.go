@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +12,9 @@ import (
 	"go.bug.st/serial/enumerator"
 )
 
+// homeStepRate is the speed of a move home, in steps per second.
+const homeStepRate = 3200
+
 type Device struct {
 	port      serial.Port
 	bufReader bufio.Reader
@@ -30,13 +34,53 @@ func (dvc *Device) Command(params ...string) (string, error) {
 	return s, err
 }
 
+// Query sends a command that responds with a value, followed by an "OK" line,
+// and returns the value.
+func (dvc *Device) Query(params ...string) (string, error) {
+	value, err := dvc.Command(params...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := dvc.bufReader.ReadString('\n'); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
 type Commander interface {
 	SteppersOn() error
 	SteppersOff() error
 	PenUp() error
 	PenDown() error
 	Move(stepsX, stepsY int, duration time.Duration) error
+	// LowLevelMove moves each motor through an accelerated move with the LM command.
+	LowLevelMove(motor1, motor2 MotorMove) error
+	// Version returns the firmware version string reported by the EBB.
+	Version() (string, error)
+	// Home moves the carriage back to the position it was in when the motors were enabled.
+	Home() error
 	Raw(command ...string) (string, error)
+
+	// QueryButton returns true if the PRG button has been pressed since it was last queried.
+	QueryButton() (bool, error)
+
+	// QueryMotion reports whether the EBB is still working through motion commands.
+	QueryMotion() (MotionStatus, error)
+}
+
+// MotionStatus is the state of the EBB's motion queue, as reported by QM.
+type MotionStatus struct {
+	// Executing is set while a motion command is being run.
+	Executing bool
+	// Motor1 and Motor2 are set while each motor is moving.
+	Motor1, Motor2 bool
+	// FIFO is set while a motion command is waiting in the FIFO to be run.
+	FIFO bool
+}
+
+// Idle returns true once every motion command has finished.
+func (s MotionStatus) Idle() bool {
+	return !s.Executing && !s.Motor1 && !s.Motor2 && !s.FIFO
 }
 
 type deviceCommander struct {
@@ -66,6 +110,73 @@ func (dc *deviceCommander) Move(stepsX, stepsY int, duration time.Duration) erro
 	return err
 }
 
+func (dc *deviceCommander) LowLevelMove(motor1, motor2 MotorMove) error {
+	_, err := dc.Command(
+		"LM",
+		strconv.Itoa(motor1.Rate), strconv.Itoa(motor1.Steps), strconv.Itoa(motor1.Accel),
+		strconv.Itoa(motor2.Rate), strconv.Itoa(motor2.Steps), strconv.Itoa(motor2.Accel),
+	)
+	return err
+}
+
+func (dc *deviceCommander) Version() (string, error) {
+	version, err := dc.Command("V")
+	return strings.TrimSpace(version), err
+}
+
+func (dc *deviceCommander) Home() error {
+	_, err := dc.Command("HM", strconv.Itoa(homeStepRate))
+	return err
+}
+
+// Reconnect closes the serial port and opens a fresh connection to the device,
+// for recovering from a dropped USB connection.
+func (dc *deviceCommander) Reconnect() error {
+	dc.port.Close()
+	dev, err := OpenDevice()
+	if err != nil {
+		return err
+	}
+	dc.Device = dev
+	return nil
+}
+
+func (dc *deviceCommander) QueryButton() (bool, error) {
+	result, err := dc.Query("QB")
+	if err != nil {
+		return false, err
+	}
+	return result == "1", nil
+}
+
+// QueryMotion sends QM, which responds with "QM,<executing>,<motor1>,<motor2>,<fifo>".
+func (dc *deviceCommander) QueryMotion() (MotionStatus, error) {
+	result, err := dc.Command("QM")
+	if err != nil {
+		return MotionStatus{}, err
+	}
+	// unlike most responses, QM's ends in "\n\r", so the '\r' is still waiting to be read
+	if b, err := dc.bufReader.ReadByte(); err != nil {
+		return MotionStatus{}, err
+	} else if b != '\r' {
+		dc.bufReader.UnreadByte()
+	}
+	fields := strings.Split(strings.TrimSpace(result), ",")
+	if len(fields) < 4 || fields[0] != "QM" {
+		return MotionStatus{}, fmt.Errorf("unexpected response to QM: %q", result)
+	}
+	// firmware before 2.4.4 doesn't report the FIFO
+	for len(fields) < 5 {
+		fields = append(fields, "0")
+	}
+	return MotionStatus{
+		Executing: fields[1] != "0",
+		Motor1:    fields[2] != "0",
+		Motor2:    fields[3] != "0",
+		FIFO:      fields[4] != "0",
+	}, nil
+}
+
 func (dc *deviceCommander) Raw(command ...string) (string, error) {
 	result, err := dc.Command(command...)
 	return result, err
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+type planExport struct {
+	Path        int             `json:"path"`
+	PenUp       bool            `json:"penUp"`
+	TotalTime   float64         `json:"totalTime"`
+	TotalLength float64         `json:"totalLength"`
+	Segments    []segmentExport `json:"segments"`
+}
+
+type segmentExport struct {
+	Start            [2]float64    `json:"start"`
+	End              [2]float64    `json:"end"`
+	MaxEntryVelocity float64       `json:"maxEntryVelocity"`
+	EntryVelocity    float64       `json:"entryVelocity"`
+	Blocks           []blockExport `json:"blocks"`
+}
+
+type blockExport struct {
+	StartTime     float64    `json:"startTime"`
+	Duration      float64    `json:"duration"`
+	Start         [2]float64 `json:"start"`
+	End           [2]float64 `json:"end"`
+	Accel         float64    `json:"accel"`
+	Jerk          float64    `json:"jerk"`
+	EntryVelocity float64    `json:"entryVelocity"`
+	ExitVelocity  float64    `json:"exitVelocity"`
+}
+
+func exportPoint(v Vec2d) [2]float64 {
+	return [2]float64{v.x, v.y}
+}
+
+func exportPlans(d Drawing, plans []Plan) []planExport {
+	out := make([]planExport, 0, len(plans))
+	for i, plan := range plans {
+		pe := planExport{
+			Path:        i,
+			PenUp:       d.paths[i].penUp,
+			TotalTime:   plan.totalTime,
+			TotalLength: plan.totalLength,
+			Segments:    make([]segmentExport, 0, len(plan.segments)),
+		}
+		for _, s := range plan.segments {
+			se := segmentExport{
+				Start:            exportPoint(s.start),
+				End:              exportPoint(s.end),
+				MaxEntryVelocity: s.maxEntryVelocity,
+				EntryVelocity:    s.entryVelocity,
+				Blocks:           make([]blockExport, 0, s.blockCount),
+			}
+			for j := s.firstBlock; j < s.firstBlock+s.blockCount; j++ {
+				b := plan.blocks[j]
+				se.Blocks = append(se.Blocks, blockExport{
+					StartTime:     plan.startTimes[j],
+					Duration:      b.t,
+					Start:         exportPoint(b.start),
+					End:           exportPoint(b.end),
+					Accel:         b.accel,
+					Jerk:          b.jerk,
+					EntryVelocity: b.velocity,
+					ExitVelocity:  b.instant(b.t).velocity,
+				})
+			}
+			pe.Segments = append(pe.Segments, se)
+		}
+		out = append(out, pe)
+	}
+	return out
+}
+
+// WritePlansJSON writes the plans for each path of the drawing as JSON,
+// nesting the blocks within the segments they were planned for.
+func WritePlansJSON(w io.Writer, d Drawing, plans []Plan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(exportPlans(d, plans))
+}
+
+var planCSVHeader = []string{
+	"path", "pen_up", "segment", "block",
+	"start_time", "duration",
+	"start_x", "start_y", "end_x", "end_y",
+	"accel", "jerk", "entry_velocity", "exit_velocity",
+	"segment_max_entry_velocity", "segment_entry_velocity",
+}
+
+// WritePlansCSV writes the plans for each path of the drawing as CSV, with
+// one row per block. Each row repeats the corner velocities of its segment.
+func WritePlansCSV(w io.Writer, d Drawing, plans []Plan) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(planCSVHeader); err != nil {
+		return err
+	}
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, pe := range exportPlans(d, plans) {
+		for i, se := range pe.Segments {
+			for j, be := range se.Blocks {
+				err := cw.Write([]string{
+					strconv.Itoa(pe.Path), strconv.FormatBool(pe.PenUp), strconv.Itoa(i), strconv.Itoa(j),
+					f(be.StartTime), f(be.Duration),
+					f(be.Start[0]), f(be.Start[1]), f(be.End[0]), f(be.End[1]),
+					f(be.Accel), f(be.Jerk), f(be.EntryVelocity), f(be.ExitVelocity),
+					f(se.MaxEntryVelocity), f(se.EntryVelocity),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func exportDrawing() (Drawing, []Plan) {
+	d := newDrawing([]Path{square(1, 1, 1), {{3, 1}, {4, 2}, {5, 1}}})
+	profiles := DefaultMotionProfiles
+	profiles.PenDown.Jerk = 200
+	return d, d.Plans(profiles)
+}
+
+func TestWritePlansJSON(t *testing.T) {
+	d, plans := exportDrawing()
+	var buf bytes.Buffer
+	if err := WritePlansJSON(&buf, d, plans); err != nil {
+		t.Fatal(err)
+	}
+	var got []planExport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := exportPlans(d, plans)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the JSON doesn't read back as the plans it was written from")
+	}
+	if len(got) != len(d.paths) {
+		t.Fatalf("got %d plans, want one for each of the %d paths", len(got), len(d.paths))
+	}
+	for i, pe := range got {
+		if pe.Path != i || pe.PenUp != d.paths[i].penUp {
+			t.Errorf("plan %d is for path %d with pen up %v", i, pe.Path, pe.PenUp)
+		}
+		// the blocks follow on from each other and add up to the plan
+		var duration float64
+		var end [2]float64
+		for j, se := range pe.Segments {
+			for k, be := range se.Blocks {
+				if (j > 0 || k > 0) && !near2(be.Start, end) {
+					t.Errorf("plan %d: block %d of segment %d starts at %v, not where the last ended at %v", i, k, j, be.Start, end)
+				}
+				if math.Abs(be.StartTime-duration) > 1e-9 {
+					t.Errorf("plan %d: block %d of segment %d starts at %vs, want %vs", i, k, j, be.StartTime, duration)
+				}
+				duration += be.Duration
+				end = be.End
+			}
+		}
+		if math.Abs(duration-pe.TotalTime) > 1e-9 {
+			t.Errorf("plan %d: the blocks take %vs, want %vs", i, duration, pe.TotalTime)
+		}
+	}
+}
+
+func TestWritePlansCSV(t *testing.T) {
+	d, plans := exportDrawing()
+	var buf bytes.Buffer
+	if err := WritePlansCSV(&buf, d, plans); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows[0], planCSVHeader) {
+		t.Errorf("got header %v, want %v", rows[0], planCSVHeader)
+	}
+	rows = rows[1:]
+
+	// each row reads back as the block it was written from
+	var blocks int
+	for _, pe := range exportPlans(d, plans) {
+		for i, se := range pe.Segments {
+			for j, be := range se.Blocks {
+				if blocks >= len(rows) {
+					t.Fatalf("got %d rows, want one for each block", len(rows))
+				}
+				row := rows[blocks]
+				blocks++
+				want := []float64{
+					float64(pe.Path), float64(i), float64(j),
+					be.StartTime, be.Duration, be.Start[0], be.Start[1], be.End[0], be.End[1],
+					be.Accel, be.Jerk, be.EntryVelocity, be.ExitVelocity,
+					se.MaxEntryVelocity, se.EntryVelocity,
+				}
+				// every column but pen_up is a number
+				columns := []int{0, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+				for k, column := range columns {
+					v, err := strconv.ParseFloat(row[column], 64)
+					if err != nil || v != want[k] {
+						t.Errorf("row %d: %s is %q, want %v", blocks, planCSVHeader[column], row[column], want[k])
+					}
+				}
+				if row[1] != strconv.FormatBool(pe.PenUp) {
+					t.Errorf("row %d: pen_up is %q, want %v", blocks, row[1], pe.PenUp)
+				}
+			}
+		}
+	}
+	if blocks != len(rows) {
+		t.Errorf("got %d rows, want %d", len(rows), blocks)
+	}
+}
+
+func near2(a, b [2]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9
+}
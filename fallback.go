@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// missingGlyph is drawn in place of characters that no font has a glyph for:
+// an empty box from the cap height down to the baseline.
+var missingGlyph = Glyph{
+	left:  -8,
+	right: 8,
+	paths: []Path{{{-5, -12}, {5, -12}, {5, 9}, {-5, 9}, {-5, -12}}},
+}
+
+// FontFallback is a font that glyphs are taken from when the main font
+// doesn't have them.
+type FontFallback struct {
+	Font Font
+	// Runes maps characters to the character whose glyph draws them in Font.
+	// If it's nil, characters are looked up in Font as they are.
+	Runes map[rune]rune
+}
+
+// glyph returns the fallback font's glyph for a character, if it has one.
+func (f FontFallback) glyph(ch rune) (Glyph, bool) {
+	if f.Runes == nil {
+		return f.Font.glyph(ch)
+	}
+	mapped, ok := f.Runes[ch]
+	if !ok {
+		return Glyph{}, false
+	}
+	return f.Font.glyph(mapped)
+}
+
+// GreekRunes maps the Greek alphabet onto the Latin letters that the Hershey
+// Greek fonts draw each letter at, following the layout of the Symbol font.
+var GreekRunes = map[rune]rune{
+	'Α': 'A', 'Β': 'B', 'Χ': 'C', 'Δ': 'D', 'Ε': 'E', 'Φ': 'F', 'Γ': 'G', 'Η': 'H',
+	'Ι': 'I', 'Κ': 'K', 'Λ': 'L', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O', 'Π': 'P', 'Θ': 'Q',
+	'Ρ': 'R', 'Σ': 'S', 'Τ': 'T', 'Υ': 'U', 'Ω': 'W', 'Ξ': 'X', 'Ψ': 'Y', 'Ζ': 'Z',
+	'α': 'a', 'β': 'b', 'χ': 'c', 'δ': 'd', 'ε': 'e', 'φ': 'f', 'γ': 'g', 'η': 'h',
+	'ι': 'i', 'κ': 'k', 'λ': 'l', 'μ': 'm', 'ν': 'n', 'ο': 'o', 'π': 'p', 'θ': 'q',
+	'ρ': 'r', 'σ': 's', 'ς': 's', 'τ': 't', 'υ': 'u', 'ω': 'w', 'ξ': 'x', 'ψ': 'y',
+	'ζ': 'z',
+}
+
+// baseLetters maps accented Latin letters to the letter without the accent,
+// which is drawn when no font has the accented one.
+var baseLetters = invertLetters(map[rune]string{
+	'A': "ÀÁÂÃÄÅĀĂĄ", 'C': "ÇĆĈĊČ", 'D': "ĎĐ", 'E': "ÈÉÊËĒĔĖĘĚ", 'G': "ĜĞĠĢ",
+	'H': "ĤĦ", 'I': "ÌÍÎÏĨĪĬĮİ", 'J': "Ĵ", 'K': "Ķ", 'L': "ĹĻĽĿŁ", 'N': "ÑŃŅŇ",
+	'O': "ÒÓÔÕÖØŌŎŐ", 'R': "ŔŖŘ", 'S': "ŚŜŞŠ", 'T': "ŢŤŦ", 'U': "ÙÚÛÜŨŪŬŮŰŲ",
+	'W': "Ŵ", 'Y': "ÝŶŸ", 'Z': "ŹŻŽ",
+	'a': "àáâãäåāăą", 'c': "çćĉċč", 'd': "ďđ", 'e': "èéêëēĕėęě", 'g': "ĝğġģ",
+	'h': "ĥħ", 'i': "ìíîïĩīĭįı", 'j': "ĵ", 'k': "ķ", 'l': "ĺļľŀł", 'n': "ñńņň",
+	'o': "òóôõöøōŏő", 'r': "ŕŗř", 's': "śŝşš", 't': "ţťŧ", 'u': "ùúûüũūŭůűų",
+	'w': "ŵ", 'y': "ýÿŷ", 'z': "źżž",
+})
+
+func invertLetters(accented map[rune]string) map[rune]rune {
+	out := map[rune]rune{}
+	for base, letters := range accented {
+		for _, ch := range letters {
+			out[ch] = base
+		}
+	}
+	return out
+}
+
+// glyphFor returns the glyph that draws a character, looking in the font and
+// then each fallback in turn. Accented letters that none of them have are
+// drawn without the accent, and anything else is drawn as missingGlyph.
+// Tabs are drawn as spaces, and other control characters aren't drawn at all.
+func glyphFor(ch rune, font Font, fallbacks []FontFallback) Glyph {
+	if ch == '\t' {
+		ch = ' '
+	} else if unicode.IsControl(ch) {
+		return Glyph{}
+	}
+	if glyph, ok := font.glyph(ch); ok {
+		return glyph
+	}
+	for _, fallback := range fallbacks {
+		if glyph, ok := fallback.glyph(ch); ok {
+			return glyph
+		}
+	}
+	if base, ok := baseLetters[ch]; ok {
+		return glyphFor(base, font, fallbacks)
+	}
+	return missingGlyph
+}
+
+// ParseFallback returns a fallback to the named font from the Fonts registry.
+// The mapping may be "" to look characters up as they are, or "greek" to use GreekRunes.
+func ParseFallback(name, mapping string) (FontFallback, error) {
+	font, err := Fonts.Lookup(name)
+	if err != nil {
+		return FontFallback{}, err
+	}
+	switch mapping {
+	case "":
+		return FontFallback{Font: font}, nil
+	case "greek":
+		return FontFallback{Font: font, Runes: GreekRunes}, nil
+	}
+	return FontFallback{}, fmt.Errorf("unknown character mapping: %s", mapping)
+}
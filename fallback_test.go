@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// widthGlyph returns a glyph that can be told apart from others by its width.
+func widthGlyph(width float64) Glyph {
+	return Glyph{left: -width / 2, right: width / 2, paths: []Path{{{-width / 2, 0}, {width / 2, 0}}}}
+}
+
+func TestGlyphFor(t *testing.T) {
+	// the main font is laid out like a Hershey font, with glyphs from ' ' up
+	font := Font{
+		glyphs: []Glyph{widthGlyph(10), widthGlyph(11)},
+		runes:  map[rune]Glyph{'e': widthGlyph(12)},
+	}
+	symbols := FontFallback{Font: Font{runes: map[rune]Glyph{'€': widthGlyph(20), '"': widthGlyph(21), 'é': widthGlyph(22)}}}
+	greek := FontFallback{Font: Font{runes: map[rune]Glyph{'b': widthGlyph(30), '€': widthGlyph(31)}}, Runes: GreekRunes}
+	fallbacks := []FontFallback{symbols, greek}
+
+	tests := []struct {
+		name string
+		ch   rune
+		want Glyph
+	}{
+		{"in the font", '!', widthGlyph(11)},
+		{"in the font's runes", 'e', widthGlyph(12)},
+		// past the end of the glyphs, and so not in the font
+		{"out of range", '"', widthGlyph(21)},
+		{"in a fallback", '€', widthGlyph(20)},
+		{"mapped by a fallback", 'β', widthGlyph(30)},
+		// the Greek fallback has 'b', but only for 'β'
+		{"not mapped by a fallback", 'b', missingGlyph},
+		{"accented in a fallback", 'é', widthGlyph(22)},
+		{"accented", 'è', widthGlyph(12)},
+		{"missing", '✓', missingGlyph},
+		{"beyond the BMP", '😀', missingGlyph},
+		{"invalid", 0xFFFD, missingGlyph},
+		{"tab", '\t', widthGlyph(10)},
+		{"control", '\a', Glyph{}},
+		{"delete", 0x7f, Glyph{}},
+		{"C1 control", 0x85, Glyph{}},
+	}
+	for _, test := range tests {
+		if got := glyphFor(test.ch, font, fallbacks); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: glyphFor(%q) = %+v, want %+v", test.name, test.ch, got, test.want)
+		}
+	}
+
+	// the fallbacks are searched in order, passing over ones that don't map the character
+	if got := glyphFor('€', font, []FontFallback{greek, symbols}); !reflect.DeepEqual(got, widthGlyph(20)) {
+		t.Errorf("the Greek fallback was used for '€', which it doesn't map, giving %+v", got)
+	}
+	unmapped := FontFallback{Font: greek.Font}
+	if got := glyphFor('€', font, []FontFallback{unmapped, symbols}); !reflect.DeepEqual(got, widthGlyph(31)) {
+		t.Errorf("the first fallback with '€' wasn't used, giving %+v", got)
+	}
+	if got := glyphFor('€', font, nil); !reflect.DeepEqual(got, missingGlyph) {
+		t.Errorf("got %+v without any fallbacks, want the missing glyph", got)
+	}
+}
+
+func TestParseFallback(t *testing.T) {
+	fallback, err := ParseFallback("futural", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallback.Runes != nil {
+		t.Error("the fallback maps characters without a mapping")
+	}
+	if _, ok := fallback.glyph('A'); !ok {
+		t.Error("the futural fallback has no 'A'")
+	}
+
+	fallback, err = ParseFallback("futural", "greek")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := fallback.Font.glyph('a')
+	if got, ok := fallback.glyph('α'); !ok || !reflect.DeepEqual(got, want) {
+		t.Error("the greek mapping doesn't draw 'α' with 'a'")
+	}
+
+	if _, err := ParseFallback("futural", "cyrillic"); err == nil {
+		t.Error("expected an error for an unknown mapping")
+	}
+	if _, err := ParseFallback("nonexistent", ""); err == nil {
+		t.Error("expected an error for an unknown font")
+	}
+}
+
+func TestLayoutUnusualCharacters(t *testing.T) {
+	layout := TextLayout{Font: layoutFont, Size: hersheyUnitsPerEm}
+	tests := []struct {
+		input, same string
+	}{
+		// control characters take up no space
+		{"a\x07a\x00", "aa"},
+		{"a\ta", "a a"},
+		// invalid UTF-8 and characters no font has are drawn as missing glyphs
+		{"a\xffa", "a✓a"},
+		{"á", "a"},
+	}
+	for _, test := range tests {
+		if got, want := strokes(layout.Layout(test.input)), strokes(layout.Layout(test.same)); !reflect.DeepEqual(got, want) {
+			t.Errorf("Layout(%q) set %v, want %v as for %q", test.input, got, want, test.same)
+		}
+	}
+
+	// a missing glyph is drawn as a box that can be plotted
+	d := TextLayout{Font: layoutFont, Size: 1, Origin: Vec2d{1, 1}}.Layout("a✓a")
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if report := sim.Report(); len(report.Violations) > 0 || report.PenLifts != 3 {
+		t.Errorf("plotting a missing glyph lifted the pen %d times, with violations %v", report.PenLifts, report.Violations)
+	}
+}
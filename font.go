@@ -1,15 +1,24 @@
 package main
 
-type Font []Glyph
+type Font struct {
+	// glyphs holds the glyphs for consecutive characters starting from space.
+	glyphs []Glyph
+	// runes holds glyphs for any other characters.
+	runes map[rune]Glyph
+	// kerning adjusts the advance from the first character of each pair to the second.
+	kerning map[[2]rune]float64
+	// outline loads glyphs and kerning from an outline font as they're needed.
+	outline *outlineFont
+}
 
 type Path []Vec2d
 
 type Glyph struct {
-	left, right int
+	left, right float64
 	paths       []Path
 }
 
-var FontAstrology Font = Font{
+var FontAstrology = Font{glyphs: []Glyph{
 	{-8, 8, []Path{}},
 	{-12, 12, []Path{{{-8, -10}, {-4, -8}, {-2, -6}, {-1, -3}, {-1, 0}, {-2, 3}, {-4, 5}, {-8, 7}}, {{-8, -10}, {-5, -9}, {-3, -8}, {-1, -6}, {0, -3}}, {{0, 0}, {-1, 3}, {-3, 5}, {-5, 6}, {-8, 7}}, {{8, -10}, {5, -9}, {3, -8}, {1, -6}, {0, -3}}, {{0, 0}, {1, 3}, {3, 5}, {5, 6}, {8, 7}}, {{8, -10}, {4, -8}, {2, -6}, {1, -3}, {1, 0}, {2, 3}, {4, 5}, {8, 7}}, {{-9, -2}, {9, -2}}, {{-9, -1}, {9, -1}}}},
 	{-9, 9, []Path{{{-4, -12}, {-5, -11}, {-5, -5}}, {{-4, -11}, {-5, -5}}, {{-4, -12}, {-3, -11}, {-5, -5}}, {{5, -12}, {4, -11}, {4, -5}}, {{5, -11}, {4, -5}}, {{5, -12}, {6, -11}, {4, -5}}}},
@@ -106,9 +115,9 @@ var FontAstrology Font = Font{
 	{-12, 12, []Path{{{-10, -3}, {-8, -7}, {-3, 3}}, {{-8, -5}, {-3, 5}, {0, -2}, {5, -2}, {8, -3}, {9, -5}, {9, -7}, {8, -9}, {6, -10}, {5, -10}, {3, -9}, {2, -7}, {2, -5}, {3, -2}, {4, 0}, {5, 3}, {5, 6}, {3, 8}}, {{5, -10}, {4, -9}, {3, -7}, {3, -5}, {5, -1}, {6, 2}, {6, 5}, {5, 7}, {3, 8}}}},
 	{-12, 12, []Path{{{-9, -3}, {-6, -6}, {-2, -4}}, {{-7, -5}, {-3, -3}, {0, -6}, {3, -4}}, {{-1, -5}, {2, -3}, {5, -6}, {7, -4}}, {{4, -5}, {6, -3}, {9, -6}}, {{-9, 3}, {-6, 0}, {-2, 2}}, {{-7, 1}, {-3, 3}, {0, 0}, {3, 2}}, {{-1, 1}, {2, 3}, {5, 0}, {7, 2}}, {{4, 1}, {6, 3}, {9, 0}}}},
 	{-12, 12, []Path{{{-9, 3}, {-9, 1}, {-8, -2}, {-6, -3}, {-4, -3}, {-2, -2}, {2, 1}, {4, 2}, {6, 2}, {8, 1}, {9, -1}}, {{-9, 1}, {-8, -1}, {-6, -2}, {-4, -2}, {-2, -1}, {2, 2}, {4, 3}, {6, 3}, {8, 2}, {9, -1}, {9, -3}}}},
-}
+}}
 
-var FontFutural = Font{
+var FontFutural = Font{glyphs: []Glyph{
 	{-8, 8, []Path{}},
 	{-5, 5, []Path{{{0, -12}, {0, 2}}, {{0, 7}, {-1, 8}, {0, 9}, {1, 8}, {0, 7}}}},
 	{-8, 8, []Path{{{-4, -12}, {-4, -5}}, {{4, -12}, {4, -5}}}},
@@ -205,4 +214,4 @@ var FontFutural = Font{
 	{-7, 7, []Path{{{-2, -16}, {0, -15}, {1, -14}, {2, -12}, {2, -10}, {1, -8}, {0, -7}, {-1, -5}, {-1, -3}, {1, -1}}, {{0, -15}, {1, -13}, {1, -11}, {0, -9}, {-1, -8}, {-2, -6}, {-2, -4}, {-1, -2}, {3, 0}, {-1, 2}, {-2, 4}, {-2, 6}, {-1, 8}, {0, 9}, {1, 11}, {1, 13}, {0, 15}}, {{1, 1}, {-1, 3}, {-1, 5}, {0, 7}, {1, 8}, {2, 10}, {2, 12}, {1, 14}, {0, 15}, {-2, 16}}}},
 	{-12, 12, []Path{{{-9, 3}, {-9, 1}, {-8, -2}, {-6, -3}, {-4, -3}, {-2, -2}, {2, 1}, {4, 2}, {6, 2}, {8, 1}, {9, -1}}, {{-9, 1}, {-8, -1}, {-6, -2}, {-4, -2}, {-2, -1}, {2, 2}, {4, 3}, {6, 3}, {8, 2}, {9, -1}, {9, -3}}}},
 	{-8, 8, []Path{{{-8, -12}, {-8, 9}, {-7, 9}, {-7, -12}, {-6, -12}, {-6, 9}, {-5, 9}, {-5, -12}, {-4, -12}, {-4, 9}, {-3, 9}, {-3, -12}, {-2, -12}, {-2, 9}, {-1, 9}, {-1, -12}, {0, -12}, {0, 9}, {1, 9}, {1, -12}, {2, -12}, {2, 9}, {3, 9}, {3, -12}, {4, -12}, {4, 9}, {5, 9}, {5, -12}, {6, -12}, {6, 9}, {7, 9}, {7, -12}, {8, -12}, {8, 9}}}},
-}
+}}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// embeddedFonts holds the fonts from the fonts directory.
+//
+//go:embed fonts
+var embeddedFonts embed.FS
+
+// FontRegistry holds fonts by name. Names are case insensitive.
+type FontRegistry struct {
+	mu    sync.RWMutex
+	fonts map[string]Font
+}
+
+func NewFontRegistry() *FontRegistry {
+	return &FontRegistry{fonts: map[string]Font{}}
+}
+
+// Fonts is the registry that text is looked up in. It starts out with the
+// compiled-in fonts and every font embedded from the fonts directory.
+var Fonts = newDefaultFontRegistry()
+
+func newDefaultFontRegistry() *FontRegistry {
+	r := NewFontRegistry()
+	r.Register("astrology", FontAstrology)
+	r.Register("futural", FontFutural)
+	if err := r.registerFontFiles(embeddedFonts, "fonts"); err != nil {
+		panic(fmt.Sprintf("failed to load embedded fonts: %s", err))
+	}
+	return r
+}
+
+// fontLoaders reads fonts in each of the supported file formats, by extension.
+var fontLoaders = map[string]func(io.Reader) (Font, error){
+	".jhf": LoadHersheyFont,
+	".svg": LoadSVGFont,
+	".ttf": LoadOutlineFont,
+	".otf": LoadOutlineFont,
+}
+
+// LoadFontFile reads a Hershey .jhf, SVG, TrueType or OpenType font from a file.
+func LoadFontFile(filename string) (Font, error) {
+	load, ok := fontLoaders[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return Font{}, fmt.Errorf("unsupported font format: %s", filename)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return Font{}, err
+	}
+	defer f.Close()
+	return load(f)
+}
+
+// registerFontFiles registers each font file in the directory under its file name.
+func (r *FontRegistry) registerFontFiles(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		load, ok := fontLoaders[strings.ToLower(ext)]
+		if entry.IsDir() || !ok {
+			continue
+		}
+		f, err := fsys.Open(path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		font, err := load(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		r.Register(strings.TrimSuffix(entry.Name(), ext), font)
+	}
+	return nil
+}
+
+// Register adds a font to the registry, replacing any font with the same name.
+func (r *FontRegistry) Register(name string, font Font) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fonts[strings.ToLower(name)] = font
+}
+
+// Lookup returns the font with the given name.
+func (r *FontRegistry) Lookup(name string) (Font, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	font, ok := r.fonts[strings.ToLower(name)]
+	if !ok {
+		return Font{}, fmt.Errorf("unknown font: %s", name)
+	}
+	return font, nil
+}
+
+// Names returns the names of the registered fonts in alphabetical order.
+func (r *FontRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.fonts))
+	for name := range r.fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterFont adds a font to the default registry, so that text can be written in it by name.
+func RegisterFont(name string, font Font) {
+	Fonts.Register(name, font)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/fs"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRegisterFontFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fonts/simplex.jhf": {Data: []byte("12345  1JZ\n 2001  9G]RFJ[ RRFZ[ RMTWT\n")},
+		"fonts/Script.JHF":  {Data: []byte("12345  1KY\n")},
+		"fonts/README.md":   {Data: []byte("# fonts\n")},
+	}
+	r := NewFontRegistry()
+	if err := r.registerFontFiles(fsys, "fonts"); err != nil {
+		t.Fatal(err)
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"script", "simplex"}) {
+		t.Errorf("registered %v, want [script simplex]", names)
+	}
+	font, err := r.Lookup("SIMPLEX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.count() != 2 {
+		t.Errorf("simplex has %d glyphs, want 2", font.count())
+	}
+	if _, err := r.Lookup("duplex"); err == nil {
+		t.Error("expected an error looking up a font that isn't registered")
+	}
+
+	fsys["fonts/broken.jhf"] = &fstest.MapFile{Data: []byte("123\n")}
+	if err := NewFontRegistry().registerFontFiles(fsys, "fonts"); err == nil {
+		t.Error("expected an error registering a broken font file")
+	}
+}
+
+func TestDefaultFonts(t *testing.T) {
+	names := []string{"astrology", "futural"}
+	entries, err := fs.ReadDir(embeddedFonts, "fonts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if _, ok := fontLoaders[strings.ToLower(ext)]; ok {
+			names = append(names, strings.TrimSuffix(entry.Name(), ext))
+		}
+	}
+	for _, name := range names {
+		font, err := Fonts.Lookup(name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		layout := TextLayout{Font: font, Size: 1}
+		if paths := layout.Layout("Hello, world").penDownPaths(); len(paths) == 0 {
+			t.Errorf("%s: laying out text drew nothing", name)
+		}
+	}
+}
@@ -2,13 +2,17 @@ module github.com/mpobrien/axigo
 
 go 1.19
 
+require (
+	github.com/chzyer/readline v1.5.1
+	github.com/fogleman/gg v1.3.0
+	go.bug.st/serial v1.6.1
+	golang.org/x/image v0.7.0
+)
+
 require (
 	github.com/bzick/tokenizer v1.3.0 // indirect
-	github.com/chzyer/readline v1.5.1 // indirect
 	github.com/creack/goselect v0.1.2 // indirect
-	github.com/fogleman/gg v1.3.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
-	go.bug.st/serial v1.6.1 // indirect
-	golang.org/x/image v0.7.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/text v0.9.0 // indirect
 )
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Hatch fills the closed paths with parallel lines spacing apart, at the
+// given angle in radians from the x axis. Where paths lie inside each
+// other, the even-odd rule decides what's filled, so the counters of
+// letters like "o" are left empty. Open paths are ignored. Successive lines
+// run in opposite directions, so that the pen doesn't have to travel back
+// across the fill between them.
+func Hatch(paths []Path, spacing, angle float64) []Path {
+	if spacing <= 0 {
+		return nil
+	}
+	// rotate everything so that the hatch lines are horizontal
+	sin, cos := math.Sincos(-angle)
+	rotate := func(p Vec2d, sin, cos float64) Vec2d {
+		return Vec2d{p.x*cos - p.y*sin, p.x*sin + p.y*cos}
+	}
+
+	var edges [][2]Vec2d
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, path := range paths {
+		if !path.closed() {
+			continue
+		}
+		for i := 1; i < len(path); i++ {
+			a, b := rotate(path[i-1], sin, cos), rotate(path[i], sin, cos)
+			edges = append(edges, [2]Vec2d{a, b})
+			minY, maxY = math.Min(minY, math.Min(a.y, b.y)), math.Max(maxY, math.Max(a.y, b.y))
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	var out []Path
+	reverse := false
+	// lines start half a space in, so that they're spread evenly across the shape
+	for y := minY + spacing/2; y < maxY; y += spacing {
+		var xs []float64
+		for _, e := range edges {
+			a, b := e[0], e[1]
+			// each edge includes its lower end but not its upper one, so that a
+			// line through a vertex crosses exactly one of the edges that meet there
+			if (a.y <= y) == (b.y <= y) {
+				continue
+			}
+			xs = append(xs, a.x+(y-a.y)*(b.x-a.x)/(b.y-a.y))
+		}
+		sort.Float64s(xs)
+		if reverse {
+			for i, j := 0, len(xs)-1; i < j; i, j = i+1, j-1 {
+				xs[i], xs[j] = xs[j], xs[i]
+			}
+		}
+		for i := 0; i+1 < len(xs); i += 2 {
+			out = append(out, Path{
+				rotate(Vec2d{xs[i], y}, -sin, cos),
+				rotate(Vec2d{xs[i+1], y}, -sin, cos),
+			})
+		}
+		reverse = !reverse
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func square(x, y, size float64) Path {
+	return Path{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}, {x, y}}
+}
+
+func TestHatch(t *testing.T) {
+	// a square with a square hole, and an open path that's ignored
+	paths := []Path{square(0, 0, 10), square(2, 2, 6), {{0, 0}, {10, 10}}}
+	lines := Hatch(paths, 1, 0)
+	// a line across each of the ten rows, split in two around the hole in six of them
+	if len(lines) != 16 {
+		t.Fatalf("got %d lines, want 16", len(lines))
+	}
+	if want := (Path{{0, 0.5}, {10, 0.5}}); !reflect.DeepEqual(lines[0], want) {
+		t.Errorf("first line is %v, want %v", lines[0], want)
+	}
+	// lines alternate direction
+	if want := (Path{{10, 1.5}, {0, 1.5}}); !reflect.DeepEqual(lines[1], want) {
+		t.Errorf("second line is %v, want %v", lines[1], want)
+	}
+	if want := []Path{{{0, 2.5}, {2, 2.5}}, {{8, 2.5}, {10, 2.5}}}; !reflect.DeepEqual(lines[2:4], want) {
+		t.Errorf("lines across the hole are %v, want %v", lines[2:4], want)
+	}
+}
+
+func TestHatchAngle(t *testing.T) {
+	lines := Hatch([]Path{square(0, 0, 10)}, 2, math.Pi/2)
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5", len(lines))
+	}
+	for i, line := range lines {
+		x := 9 - 2*float64(i)
+		for _, p := range line {
+			if math.Abs(p.x-x) > 1e-9 {
+				t.Errorf("line %d is %v, want it vertical at x = %v", i, line, x)
+				break
+			}
+		}
+		if length := line[1].Distance(line[0]); math.Abs(length-10) > 1e-9 {
+			t.Errorf("line %d is %v long, want 10", i, length)
+		}
+	}
+}
+
+func TestHatchNothing(t *testing.T) {
+	if lines := Hatch([]Path{square(0, 0, 10)}, 0, 0); lines != nil {
+		t.Errorf("got %v with no spacing, want nothing", lines)
+	}
+	if lines := Hatch([]Path{{{0, 0}, {10, 0}, {10, 10}}}, 1, 0); lines != nil {
+		t.Errorf("got %v for an open path, want nothing", lines)
+	}
+}
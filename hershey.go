@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// hersheyOrigin is the character that Hershey coordinates are measured from.
+const hersheyOrigin = 'R'
+
+// LoadHersheyFont reads a font in the Hershey .jhf format, where each glyph
+// is a line of the form:
+//
+//	NNNNNCCCLRxyxyxy...
+//
+// NNNNN is the glyph number and CCC the number of coordinate pairs that
+// follow, including the left and right bearings L and R. Each coordinate is
+// a character's offset from 'R', and the pair " R" lifts the pen. Long
+// glyphs may be wrapped across several lines. The glyphs are taken to be
+// for consecutive characters starting at space, as in the standard fonts.
+func LoadHersheyFont(r io.Reader) (Font, error) {
+	var font Font
+	scanner := bufio.NewScanner(r)
+	var pending string
+	var lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if pending == "" && strings.TrimSpace(line) == "" {
+			continue
+		}
+		pending += line
+
+		if len(pending) < 8 {
+			return Font{}, fmt.Errorf("line %d: glyph header is too short", lineNumber)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(pending[5:8]))
+		if err != nil || count < 1 {
+			return Font{}, fmt.Errorf("line %d: invalid coordinate count %q", lineNumber, pending[5:8])
+		}
+		if len(pending) < 8+2*count {
+			// the glyph continues on the next line
+			continue
+		}
+		glyph, err := parseHersheyGlyph(pending[8 : 8+2*count])
+		if err != nil {
+			return Font{}, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		font.glyphs = append(font.glyphs, glyph)
+		pending = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return Font{}, err
+	}
+	if pending != "" {
+		return Font{}, fmt.Errorf("line %d: glyph is truncated", lineNumber)
+	}
+	return font, nil
+}
+
+// parseHersheyGlyph parses the bearings and coordinates of a glyph.
+func parseHersheyGlyph(data string) (Glyph, error) {
+	glyph := Glyph{
+		left:  float64(int(data[0]) - hersheyOrigin),
+		right: float64(int(data[1]) - hersheyOrigin),
+	}
+	var path Path
+	for i := 2; i+1 < len(data); i += 2 {
+		if data[i:i+2] == " R" {
+			if len(path) > 0 {
+				glyph.paths = append(glyph.paths, path)
+			}
+			path = nil
+			continue
+		}
+		if data[i] < ' ' || data[i+1] < ' ' {
+			return Glyph{}, fmt.Errorf("invalid coordinate %q", data[i:i+2])
+		}
+		x, y := int(data[i])-hersheyOrigin, int(data[i+1])-hersheyOrigin
+		path = append(path, Vec2d{float64(x), float64(y)})
+	}
+	if len(path) > 0 {
+		glyph.paths = append(glyph.paths, path)
+	}
+	return glyph, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadHersheyFont(t *testing.T) {
+	// a space, and an A whose data is wrapped onto a second line
+	data := "12345  1JZ\n" +
+		" 2001  9G]RFJ[ RRFZ\n" +
+		"[ RMTWT\n"
+	font, err := LoadHersheyFont(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(font.glyphs) != 2 {
+		t.Fatalf("got %d glyphs, want 2", len(font.glyphs))
+	}
+	space, a := font.glyphs[0], font.glyphs[1]
+	if space.left != -8 || space.right != 8 || len(space.paths) != 0 {
+		t.Errorf("space = %+v, want bearings -8 and 8 and no paths", space)
+	}
+	want := Glyph{left: -11, right: 11, paths: []Path{
+		{{0, -12}, {-8, 9}},
+		{{0, -12}, {8, 9}},
+		{{-5, 2}, {5, 2}},
+	}}
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("A = %+v, want %+v", a, want)
+	}
+	if glyph, ok := font.glyph('!'); !ok || !reflect.DeepEqual(glyph, want) {
+		t.Errorf("glyph('!') = %+v, %v, want the second glyph", glyph, ok)
+	}
+}
+
+func TestLoadHersheyFontErrors(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"short header", "123\n"},
+		{"invalid count", "12345 xxJZ\n"},
+		{"zero count", "12345  0JZ\n"},
+		{"truncated glyph", "12345  3JZRF\n"},
+		{"control character", "12345  2JZR\x01\n"},
+	}
+	for _, test := range tests {
+		if _, err := LoadHersheyFont(strings.NewReader(test.data)); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
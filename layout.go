@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// hersheyUnitsPerEm is the height of a Hershey font's em square, which
+	// runs from hersheyEmTop down to 16 units below the origin.
+	hersheyUnitsPerEm = 32
+	hersheyEmTop      = -16
+	// hersheyBaseline is where the baseline of a Hershey glyph lies, in font units below its origin.
+	hersheyBaseline = 9
+
+	millimetersPerInch = 25.4
+	pointsPerInch      = 72
+)
+
+// Millimeters converts a length in millimeters to inches.
+func Millimeters(mm float64) float64 {
+	return mm / millimetersPerInch
+}
+
+// Points converts a length in points to inches.
+func Points(pt float64) float64 {
+	return pt / pointsPerInch
+}
+
+// ParseLength parses a length with an optional unit of "in", "mm" or "pt",
+// returning it in inches. Lengths without a unit are in inches.
+func ParseLength(s string) (float64, error) {
+	number, scale := s, float64(1)
+	switch {
+	case strings.HasSuffix(s, "mm"):
+		number, scale = strings.TrimSuffix(s, "mm"), 1/millimetersPerInch
+	case strings.HasSuffix(s, "pt"):
+		number, scale = strings.TrimSuffix(s, "pt"), 1.0/pointsPerInch
+	case strings.HasSuffix(s, "in"):
+		number = strings.TrimSuffix(s, "in")
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length: %s", s)
+	}
+	return v * scale, nil
+}
+
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignCenter
+	AlignRight
+	// AlignJustify stretches the spaces between words so that every line
+	// but the last of each paragraph fills the width of the box.
+	AlignJustify
+)
+
+// ParseAlignment returns the alignment with the given name: "left", "center", "right" or "justify".
+func ParseAlignment(name string) (Alignment, error) {
+	switch name {
+	case "left":
+		return AlignLeft, nil
+	case "center", "centre":
+		return AlignCenter, nil
+	case "right":
+		return AlignRight, nil
+	case "justify":
+		return AlignJustify, nil
+	}
+	return AlignLeft, fmt.Errorf("unknown alignment: %s", name)
+}
+
+// TextLayout describes how to set text on the page. Lengths are in inches;
+// use Millimeters and Points to convert from other units.
+type TextLayout struct {
+	Font Font
+	// Fallbacks are searched in order for characters that Font doesn't have.
+	Fallbacks []FontFallback
+	// Size is the height of the font's em square.
+	Size float64
+	// LetterSpacing is the extra space added after each letter.
+	LetterSpacing float64
+	// LineSpacing is the distance between baselines, as a multiple of Size.
+	// If unset, lines are Size apart.
+	LineSpacing float64
+	// Width is the width of the box that lines are wrapped to and aligned
+	// within. If unset, lines are only broken at newlines, and they're
+	// aligned within the width of the longest one.
+	Width float64
+	Align Alignment
+	// Origin is the top left corner of the text box on the page.
+	Origin Vec2d
+	// Hatch is the spacing of the lines that fill in the closed outlines of
+	// glyphs, such as those of outline fonts. If unset, they aren't filled.
+	Hatch float64
+	// HatchAngle is the angle of the hatch lines from the x axis, in radians.
+	HatchAngle float64
+	// Variation makes the glyphs irregular, like handwriting. If unset,
+	// every copy of a glyph is drawn the same.
+	Variation TextVariation
+}
+
+// layoutWord is a run of characters without spaces, positioned along a line.
+type layoutWord struct {
+	text string
+	// start is the index of the word's first character in the input.
+	start int
+	x     float64
+	width float64
+}
+
+// Layout sets the input in the font and returns it as a drawing in page
+// coordinates. The input is split into paragraphs at newlines, and
+// paragraphs are wrapped at spaces to fit the width.
+func (l TextLayout) Layout(input string) Drawing {
+	scale := l.Size / hersheyUnitsPerEm
+	lineHeight := l.Size
+	if l.LineSpacing > 0 {
+		lineHeight *= l.LineSpacing
+	}
+
+	var lines [][]layoutWord
+	var lastLines []bool
+	start := 0
+	// tabs break lines like spaces do
+	input = strings.ReplaceAll(input, "\t", " ")
+	for _, paragraph := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		wrapped := l.wrap(paragraph, start, scale)
+		for i, line := range wrapped {
+			lines = append(lines, line)
+			lastLines = append(lastLines, i == len(wrapped)-1)
+		}
+		start += utf8.RuneCountInString(paragraph) + 1
+	}
+
+	width := l.Width
+	if width <= 0 {
+		for _, line := range lines {
+			if w := lineWidth(line); w > width {
+				width = w
+			}
+		}
+	}
+
+	var paths []Path
+	for i, line := range lines {
+		l.align(line, width, lastLines[i])
+		baseline := Vec2d{l.Origin.x, l.Origin.y + float64(i)*lineHeight - hersheyEmTop*scale}
+		for _, word := range line {
+			x := baseline.x + word.x
+			var prev rune
+			var prevSource int
+			index := word.start
+			for _, ch := range word.text {
+				glyph, source := l.glyphAt(ch, index)
+				x += l.kern(prev, ch, prevSource, source) * scale
+				prev, prevSource = ch, source
+				vary := l.vary(index, (glyph.right-glyph.left)*scale)
+				for _, path := range glyph.paths {
+					paths = append(paths, placePath(path, func(point Vec2d) Vec2d {
+						p := vary(Vec2d{(point.x - glyph.left) * scale, point.y * scale})
+						return Vec2d{x + p.x, baseline.y + p.y}
+					}))
+				}
+				x += l.advance(glyph, scale)
+				index++
+			}
+		}
+	}
+	return l.drawing(paths)
+}
+
+// drawing returns the placed glyphs as a drawing, adding hatching if it's enabled.
+func (l TextLayout) drawing(paths []Path) Drawing {
+	if l.Hatch > 0 {
+		paths = append(paths, Hatch(paths, l.Hatch, l.HatchAngle)...)
+	}
+	return newDrawing(paths)
+}
+
+// wrap breaks a paragraph into lines of words that fit within the width,
+// with each word positioned as if the line were left aligned. A word that's
+// wider than the box gets a line to itself.
+func (l TextLayout) wrap(paragraph string, start int, scale float64) [][]layoutWord {
+	space := l.advance(l.glyph(' '), scale)
+	var lines [][]layoutWord
+	var line []layoutWord
+	x := float64(0)
+	for _, text := range strings.Split(paragraph, " ") {
+		wordStart := start
+		start += utf8.RuneCountInString(text) + 1
+		if text == "" {
+			// keep runs of spaces as they are
+			x += space
+			continue
+		}
+		width := l.measure(text, wordStart, scale)
+		if l.Width > 0 && len(line) > 0 && x+width > l.Width {
+			lines = append(lines, line)
+			line, x = nil, 0
+		}
+		line = append(line, layoutWord{text, wordStart, x, width})
+		x += width + space
+	}
+	return append(lines, line)
+}
+
+// align moves the words of the line to their place within the given width.
+func (l TextLayout) align(line []layoutWord, width float64, last bool) {
+	extra := width - lineWidth(line)
+	switch l.Align {
+	case AlignCenter:
+		shiftWords(line, extra/2)
+	case AlignRight:
+		shiftWords(line, extra)
+	case AlignJustify:
+		if last || len(line) < 2 || extra <= 0 {
+			return
+		}
+		gap := extra / float64(len(line)-1)
+		for i := range line {
+			line[i].x += gap * float64(i)
+		}
+	}
+}
+
+func shiftWords(line []layoutWord, dx float64) {
+	for i := range line {
+		line[i].x += dx
+	}
+}
+
+// lineWidth returns the distance from the start of the line to the end of its last word.
+func lineWidth(line []layoutWord) float64 {
+	if len(line) == 0 {
+		return 0
+	}
+	last := line[len(line)-1]
+	return last.x + last.width
+}
+
+// measure returns the width of the text, which starts at the given index
+// in the input, leaving out the letter spacing after its last letter.
+func (l TextLayout) measure(text string, start int, scale float64) float64 {
+	var width float64
+	var prev rune
+	var prevSource int
+	index := start
+	for _, ch := range text {
+		glyph, source := l.glyphAt(ch, index)
+		width += l.kern(prev, ch, prevSource, source)*scale + l.advance(glyph, scale)
+		prev, prevSource = ch, source
+		index++
+	}
+	if width > 0 {
+		width -= l.LetterSpacing
+	}
+	return width
+}
+
+// advance returns how far along the line a glyph moves the next one.
+func (l TextLayout) advance(glyph Glyph, scale float64) float64 {
+	return (glyph.right-glyph.left)*scale + l.LetterSpacing
+}
+
+// glyph returns the glyph that draws a character, from the font or its fallbacks.
+func (l TextLayout) glyph(ch rune) Glyph {
+	return glyphFor(ch, l.Font, l.Fallbacks)
+}
+
+// glyph returns the glyph for a character, if the font has one.
+func (font Font) glyph(ch rune) (Glyph, bool) {
+	if index := int(ch) - ' '; index >= 0 && index < len(font.glyphs) {
+		return font.glyphs[index], true
+	}
+	if glyph, ok := font.runes[ch]; ok {
+		return glyph, true
+	}
+	if font.outline != nil {
+		return font.outline.glyph(ch)
+	}
+	return Glyph{}, false
+}
+
+// kern returns the adjustment to the advance from one character to the next.
+func (font Font) kern(a, b rune) float64 {
+	if font.outline != nil {
+		return font.outline.kern(a, b)
+	}
+	return font.kerning[[2]rune{a, b}]
+}
+
+// count returns the number of characters the font has glyphs for.
+func (font Font) count() int {
+	count := len(font.glyphs) + len(font.runes)
+	if font.outline != nil {
+		count += len(font.outline.chars)
+	}
+	return count
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// layoutFont draws each letter as a stroke along its baseline, from its left
+// edge to its right, so that where the letters were set can be read back.
+var layoutFont = Font{
+	runes: map[rune]Glyph{
+		' ': {left: -5, right: 5},
+		'a': {left: -4, right: 4, paths: []Path{{{-4, hersheyBaseline}, {4, hersheyBaseline}}}},
+		'b': {left: -6, right: 6, paths: []Path{{{-6, hersheyBaseline}, {6, hersheyBaseline}}}},
+	},
+}
+
+// stroke is where a letter of layoutFont was set: from x0 to x1 along the baseline at y.
+type stroke struct{ x0, x1, y float64 }
+
+func strokes(d Drawing) []stroke {
+	var out []stroke
+	for _, path := range d.penDownPaths() {
+		round := func(v float64) float64 { return math.Round(v*1e6) / 1e6 }
+		out = append(out, stroke{round(path[0].x), round(path[len(path)-1].x), round(path[0].y)})
+	}
+	return out
+}
+
+func TestLayout(t *testing.T) {
+	// at a size of one em, inches are font units, and the first baseline is
+	// the em's height above its baseline below the top of the box
+	const first, second, third = 25, 57, 89
+	tests := []struct {
+		name   string
+		layout TextLayout
+		input  string
+		want   []stroke
+	}{
+		{"words", TextLayout{}, "a b", []stroke{{0, 8, first}, {18, 30, first}}},
+		{"letters", TextLayout{}, "ab", []stroke{{0, 8, first}, {8, 20, first}}},
+		{"letter spacing", TextLayout{LetterSpacing: 1}, "aa", []stroke{{0, 8, first}, {9, 17, first}}},
+		{"runs of spaces", TextLayout{}, "a  a", []stroke{{0, 8, first}, {28, 36, first}}},
+		{"tabs", TextLayout{}, "a\ta", []stroke{{0, 8, first}, {18, 26, first}}},
+		{"newlines", TextLayout{}, "a\r\nb", []stroke{{0, 8, first}, {0, 12, second}}},
+		{"blank lines", TextLayout{}, "a\n\na", []stroke{{0, 8, first}, {0, 8, third}}},
+		{"line spacing", TextLayout{LineSpacing: 1.5}, "a\na", []stroke{{0, 8, first}, {0, 8, first + 48}}},
+		{"size", TextLayout{Size: 2 * hersheyUnitsPerEm}, "a\na", []stroke{{0, 16, 50}, {0, 16, 114}}},
+		{"origin", TextLayout{Origin: Vec2d{1, 2}}, "a", []stroke{{1, 9, first + 2}}},
+
+		{"wrap", TextLayout{Width: 30}, "a a a", []stroke{{0, 8, first}, {18, 26, first}, {0, 8, second}}},
+		{"wrap exactly", TextLayout{Width: 26}, "a a a", []stroke{{0, 8, first}, {18, 26, first}, {0, 8, second}}},
+		{"long word", TextLayout{Width: 20}, "bbb a", []stroke{{0, 12, first}, {12, 24, first}, {24, 36, first}, {0, 8, second}}},
+		{"no wrap", TextLayout{}, "a a a", []stroke{{0, 8, first}, {18, 26, first}, {36, 44, first}}},
+
+		{"center", TextLayout{Width: 20, Align: AlignCenter}, "a", []stroke{{6, 14, first}}},
+		{"right", TextLayout{Width: 20, Align: AlignRight}, "a", []stroke{{12, 20, first}}},
+		{"justify", TextLayout{Width: 30, Align: AlignJustify}, "a a a", []stroke{{0, 8, first}, {22, 30, first}, {0, 8, second}}},
+		{"justify paragraphs", TextLayout{Width: 30, Align: AlignJustify}, "a a\na", []stroke{{0, 8, first}, {18, 26, first}, {0, 8, second}}},
+		// without a width, lines are aligned within the longest
+		{"center lines", TextLayout{Align: AlignCenter}, "a\na a", []stroke{{9, 17, first}, {0, 8, second}, {18, 26, second}}},
+		{"right lines", TextLayout{Align: AlignRight}, "a\na a", []stroke{{18, 26, first}, {0, 8, second}, {18, 26, second}}},
+	}
+	for _, test := range tests {
+		layout := test.layout
+		layout.Font = layoutFont
+		if layout.Size == 0 {
+			layout.Size = hersheyUnitsPerEm
+		}
+		if got := strokes(layout.Layout(test.input)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Layout(%q) set %v, want %v", test.name, test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tests := map[string]float64{
+		"2":      2,
+		"1.5in":  1.5,
+		"25.4mm": 1,
+		"36pt":   0.5,
+		"-1mm":   -1 / millimetersPerInch,
+	}
+	for s, want := range tests {
+		if got, err := ParseLength(s); err != nil || math.Abs(got-want) > 1e-12 {
+			t.Errorf("ParseLength(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "mm", "1cm", "one"} {
+		if _, err := ParseLength(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestParseAlignment(t *testing.T) {
+	tests := map[string]Alignment{
+		"left":    AlignLeft,
+		"center":  AlignCenter,
+		"centre":  AlignCenter,
+		"right":   AlignRight,
+		"justify": AlignJustify,
+	}
+	for name, want := range tests {
+		if got, err := ParseAlignment(name); err != nil || got != want {
+			t.Errorf("ParseAlignment(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseAlignment("middle"); err == nil {
+		t.Error("expected an error for an unknown alignment")
+	}
+}
+
+func TestPlotText(t *testing.T) {
+	font, err := Fonts.Lookup("futural")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := TextLayout{Font: font, Size: 0.5, Width: 4, Origin: Vec2d{1, 1}}
+	d := layout.Layout("The quick brown fox jumps over the lazy dog")
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	report := sim.Report()
+	if len(report.Violations) > 0 {
+		t.Errorf("plotting text broke the limits: %v", report.Violations)
+	}
+	if stats := d.Stats(); report.PenLifts != stats.PenLifts {
+		t.Errorf("the plot lifted the pen %d times, want %d", report.PenLifts, stats.PenLifts)
+	}
+	// the text was wrapped within the box
+	for _, path := range sim.paths {
+		if path.penUp {
+			continue
+		}
+		for _, p := range path.Path {
+			if p := p.Multiply(1.0 / simRenderScale); p.x < 1-0.01 || p.x > 5+0.01 || p.y < 1-0.01 {
+				t.Fatalf("the text was drawn at %v, outside its box", p)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// lmTickRate is the frequency of the EBB's step loop, in ticks per second.
+	lmTickRate = 25000
+
+	// lmRateScale converts a step rate in steps per second into the units of the
+	// LM command, where the rate is added to a 31 bit accumulator on every tick.
+	lmRateScale = (1 << 31) / float64(lmTickRate)
+
+	// lmMinStepRate keeps the motors from stalling at the end of a move that
+	// slows to a stop, in case rounding leaves them a fraction of a step short.
+	lmMinStepRate = 50
+)
+
+// MotorMove describes the motion of a single motor in an LM command.
+// Rate is the initial step rate and Accel is added to it on every tick,
+// both in units of 1/2^31 steps per tick.
+type MotorMove struct {
+	Rate  int
+	Steps int
+	Accel int
+}
+
+// supportsLowLevelMoves returns true if the firmware version string reported
+// by the EBB is new enough to have the LM command (2.7.0 and above).
+func supportsLowLevelMoves(version string) bool {
+	i := strings.Index(version, "Version ")
+	if i < 0 {
+		return false
+	}
+	var major, minor, patch int
+	if _, err := fmt.Sscanf(version[i:], "Version %d.%d.%d", &major, &minor, &patch); err != nil {
+		return false
+	}
+	if major != 2 {
+		return major > 2
+	}
+	return minor >= 7
+}
+
+// newMotorMove computes the LM parameters for a motor that has to take the
+// given number of steps, moving at speed v0 and ending at v1 (in steps per
+// second, changing at a constant rate) over the given number of seconds.
+func newMotorMove(steps int, v0, v1, duration float64) MotorMove {
+	if steps == 0 {
+		return MotorMove{}
+	}
+	v1 = math.Max(v1, lmMinStepRate)
+	ticks := duration * lmTickRate
+	return MotorMove{
+		Rate:  int(math.Round(v0 * lmRateScale)),
+		Steps: steps,
+		Accel: int(math.Round((v1 - v0) * lmRateScale / ticks)),
+	}
+}
+
+// runPlanLowLevel sends each block of the plan as a single LM command, so
+// that the EBB does the acceleration itself. Blocks with jerk can't be
+// expressed that way, so they're broken up into pieces of constant
+// acceleration, one per timeslice.
+func (p *plotter) runPlanLowLevel(plan Plan, onMove func(Instant)) error {
+	for i, b := range plan.blocks {
+		pieces := 1
+		if b.jerk != 0 {
+			pieces = int(math.Ceil(b.t / timeslice.Seconds()))
+		}
+		for j := 0; j < pieces; j++ {
+			i1 := b.instant(b.t * float64(j) / float64(pieces))
+			i2 := b.instant(b.t * float64(j+1) / float64(pieces))
+			if err := p.safePoint(i1.velocity); err != nil {
+				return err
+			}
+			if err := p.lowLevelMove(i1, i2); err != nil {
+				return err
+			}
+			if err := p.moved(onMove, plan.instant(plan.startTimes[i]+i2.t)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lowLevelMove moves in a straight line between two instants, with the
+// velocity changing at a constant rate between them.
+func (p *plotter) lowLevelMove(from, to Instant) error {
+	d := to.position.Subtract(from.position)
+	var sx, sy float64
+	sx, p.errX = math.Modf(d.x*p.stepsPerUnit + p.errX)
+	sy, p.errY = math.Modf(d.y*p.stepsPerUnit + p.errY)
+	stepsX, stepsY := int(sx), int(sy)
+	if stepsX == 0 && stepsY == 0 {
+		return nil
+	}
+
+	length := to.position.Distance(from.position)
+	duration := to.t - from.t
+	// the AxiDraw's motors each drive a combination of the X and Y axes
+	motor1, motor2 := stepsX+stepsY, stepsX-stepsY
+	move := func(steps int) MotorMove {
+		stepsPerUnit := math.Abs(float64(steps)) / length
+		return newMotorMove(steps, from.velocity*stepsPerUnit, to.velocity*stepsPerUnit, duration)
+	}
+	m1, m2 := move(motor1), move(motor2)
+	err := p.send(func(cmdr Commander) error {
+		return cmdr.LowLevelMove(m1, m2)
+	})
+	if err != nil {
+		return err
+	}
+	p.x += stepsX
+	p.y += stepsY
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSupportsLowLevelMoves(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"EBBv13_and_above EB Firmware Version 2.8.1", true},
+		{"EBBv13_and_above EB Firmware Version 2.7.0", true},
+		{"EBBv13_and_above EB Firmware Version 2.6.5", false},
+		{"EBBv13_and_above EB Firmware Version 2.5.3", false},
+		{"EBBv13_and_above EB Firmware Version 3.0.0", true},
+		{"EBBv13_and_above EB Firmware Version 1.9.9", false},
+		{"EBBv13_and_above EB Firmware", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := supportsLowLevelMoves(test.version); got != test.want {
+			t.Errorf("supportsLowLevelMoves(%q) = %v, want %v", test.version, got, test.want)
+		}
+	}
+}
+
+// firmwareSimulator is a Simulator that reports the given firmware version
+// and counts the kinds of moves it's sent.
+type firmwareSimulator struct {
+	*Simulator
+	version string
+	xm, lm  int
+}
+
+func (f *firmwareSimulator) Version() (string, error) {
+	return f.version, nil
+}
+
+func (f *firmwareSimulator) Move(stepsX, stepsY int, duration time.Duration) error {
+	f.xm++
+	return f.Simulator.Move(stepsX, stepsY, duration)
+}
+
+func (f *firmwareSimulator) LowLevelMove(motor1, motor2 MotorMove) error {
+	f.lm++
+	return f.Simulator.LowLevelMove(motor1, motor2)
+}
+
+func TestLowLevelMoveFallback(t *testing.T) {
+	d := newDrawing([]Path{square(1, 1, 1), {{3, 1}, {4, 2}, {5, 1}}})
+	jerk := DefaultMotionProfiles
+	jerk.PenUp.Jerk, jerk.PenDown.Jerk = 200, 200
+	for _, profiles := range []MotionProfiles{DefaultMotionProfiles, jerk} {
+		newer := &firmwareSimulator{Simulator: NewSimulator(), version: "EBBv13_and_above EB Firmware Version 2.8.1"}
+		older := &firmwareSimulator{Simulator: NewSimulator(), version: "EBBv13_and_above EB Firmware Version 2.5.3"}
+		for _, sim := range []*firmwareSimulator{newer, older} {
+			if err := PlotDrawing(sim, d, PlotOptions{Profiles: profiles}); err != nil {
+				t.Fatal(err)
+			}
+			if len(sim.violations) != 0 {
+				t.Errorf("firmware %q: got violations %v", sim.version, sim.violations)
+			}
+		}
+		// firmware without LM gets timesliced XM moves instead
+		if newer.lm == 0 || newer.xm != 0 {
+			t.Errorf("new firmware was sent %d LM and %d XM moves, want only LM", newer.lm, newer.xm)
+		}
+		if older.lm != 0 || older.xm == 0 {
+			t.Errorf("old firmware was sent %d LM and %d XM moves, want only XM", older.lm, older.xm)
+		}
+		// and ends up in the same place in about the same time
+		if newer.x != older.x || newer.y != older.y {
+			t.Errorf("LM moves ended at (%d, %d) steps and XM moves at (%d, %d)", newer.x, newer.y, older.x, older.y)
+		}
+		if diff := math.Abs((newer.elapsed - older.elapsed).Seconds()); diff > 0.05*newer.elapsed.Seconds() {
+			t.Errorf("LM moves took %s and XM moves %s", newer.elapsed, older.elapsed)
+		}
+	}
+}
+
+func TestNewMotorMove(t *testing.T) {
+	tests := []struct {
+		steps            int
+		v0, v1, duration float64
+		// moves that slow to a stop end at lmMinStepRate instead, which
+		// finishes their steps a little early
+		early float64
+	}{
+		{1000, 0, 2000, 1, 0},
+		{500, 1000, 1000, 0.5, 0},
+		{2000, 1000, 3000, 1, 0},
+		{-1000, 2000, 0, 1, 0.15},
+	}
+	for _, test := range tests {
+		m := newMotorMove(test.steps, test.v0, test.v1, test.duration)
+		if m.Steps != test.steps {
+			t.Errorf("%+v: got %d steps", test, m.Steps)
+		}
+		ticks, ok := motorTicks(m)
+		if !ok {
+			t.Errorf("%+v: the motor never completes its steps", test)
+			continue
+		}
+		got := ticks / lmTickRate
+		if got > test.duration*1.01 || got < test.duration*(0.99-test.early) {
+			t.Errorf("%+v: the move takes %vs", test, got)
+		}
+	}
+	if m := newMotorMove(0, 100, 100, 1); m != (MotorMove{}) {
+		t.Errorf("a motor with no steps got %+v", m)
+	}
+}
@@ -2,14 +2,17 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
 	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/chzyer/readline"
 	"github.com/fogleman/gg"
@@ -19,11 +22,49 @@ const (
 	stepsPerInch               = 2032
 	stepsPerMillimeter         = 80
 	defaultSpeedStepsPerSecond = 2032
+
+	// previewScale is the number of pixels per inch in velocity previews
+	previewScale = 200
+)
+
+var (
+	dryRun            = flag.Bool("dry-run", false, "simulate plots without connecting to the device")
+	checkpointDir     = flag.String("checkpoint-dir", DefaultCheckpointDir(), "directory to save plot progress in, for resuming interrupted plots")
+	simplifyMethod    = flag.String("simplify", "none", "simplify paths before planning: none, dp (Douglas-Peucker) or vw (Visvalingam)")
+	simplifyTolerance = flag.Float64("simplify-tolerance", 0.002, "how far a simplified path may stray from the original, in inches")
+	curveTolerance    = flag.Float64("curve-tolerance", DefaultCurveTolerance, "how far flattened arcs and curves may stray from the true curve, in inches")
+	validatePlans     = flag.Bool("validate", false, "check each plan against the motion limits before running it")
+	planWorkers       = flag.Int("plan-workers", 0, "number of paths to plan at once while plotting (0 for one per CPU)")
+	fontName          = flag.String("font", "astrology", "font to write text in: a registered or installed font name, or a Hershey .jhf, SVG, TrueType or OpenType font file")
+	penUpProfile      = profileFlags("up", "pen-up travel", DefaultMotionProfiles.PenUp)
+	penDownProfile    = profileFlags("down", "pen-down drawing", DefaultMotionProfiles.PenDown)
 )
 
-func readEvalPrint(input string, cmdr Commander) error {
+var (
+	// activeFont is the name of the font that text is written in, switched with the 'font' command.
+	activeFont = "astrology"
+
+	// textSettings is how text is laid out on the page, changed with the
+	// 'size', 'align', 'wrap', 'spacing', 'fallback', 'hatch',
+	// 'handwriting' and 'alternates' commands.
+	textSettings = TextLayout{Size: 1, Origin: Vec2d{1, 1}}
+)
+
+// profileFlags registers flags for setting each limit of a motion profile, starting from the given defaults.
+func profileFlags(prefix, usage string, defaults MotionProfile) *MotionProfile {
+	profile := defaults
+	flag.Float64Var(&profile.Accel, prefix+"-accel", defaults.Accel, "acceleration for "+usage+", in inches/s^2")
+	flag.Float64Var(&profile.MaxVelocity, prefix+"-speed", defaults.MaxVelocity, "max velocity for "+usage+", in inches/s")
+	flag.Float64Var(&profile.CornerFactor, prefix+"-corner", defaults.CornerFactor, "cornering factor for "+usage)
+	flag.Float64Var(&profile.Jerk, prefix+"-jerk", defaults.Jerk, "jerk limit for "+usage+", in inches/s^3 (0 for constant acceleration)")
+	return &profile
+}
+
+func readEvalPrint(input string, cmdr Commander, opts PlotOptions) error {
 	cmds := strings.Split(input, ";")
 	for _, cmd := range cmds {
+		// file names are case sensitive, so keep the original parts around
+		rawParts := strings.Fields(cmd)
 		cmd = strings.TrimSpace(strings.ToLower(cmd))
 		cmdParts := strings.Fields(cmd)
 		if len(cmdParts) == 0 {
@@ -91,14 +132,21 @@ func readEvalPrint(input string, cmdr Commander) error {
 			}
 			continue
 		case "text":
-			if len(cmdParts[1:]) != 1 {
+			if len(cmdParts[1:]) != 1 && len(cmdParts[1:]) != 2 {
 				return fmt.Errorf("incorrect param count to 'text'")
 			}
-			textPaths := text(cmdParts[1], FontAstrology)
+			fontName := activeFont
+			if len(cmdParts) == 3 {
+				fontName = rawParts[2]
+			}
+			textPaths, err := text(rawParts[1], fontName)
+			if err != nil {
+				return err
+			}
 			d := newDrawing(textPaths)
 
 			for i, path := range d.paths {
-				plan := makePlan([]Vec2d(path.Path), float64(16), 0.001, i == 3)
+				plan := makePlan(path.Path, opts.Profiles.forPath(path), i == 3)
 				for j, block := range plan.blocks {
 					fmt.Printf(
 						"%d, %d: a=%.2f, t=%.2f, vi=%.2f, p1=%s, p2=%s\n",
@@ -114,7 +162,7 @@ func readEvalPrint(input string, cmdr Commander) error {
 
 				}
 			}
-			stats := d.Stats()
+			stats := d.EstimateStats(opts.Profiles)
 			fmt.Printf("stats: %#v\n", stats)
 			encoded, err := d.Render()
 			if err != nil {
@@ -136,6 +184,345 @@ func readEvalPrint(input string, cmdr Commander) error {
 				return err
 			}
 
+			continue
+		case "font":
+			if len(cmdParts[1:]) != 1 {
+				return fmt.Errorf("incorrect param count to 'font'")
+			}
+			if err := selectFont(rawParts[1]); err != nil {
+				return err
+			}
+			continue
+		case "size":
+			if len(cmdParts[1:]) != 1 {
+				return fmt.Errorf("incorrect param count to 'size'")
+			}
+			size, err := ParseLength(cmdParts[1])
+			if err != nil {
+				return err
+			}
+			textSettings.Size = size
+			continue
+		case "align":
+			if len(cmdParts[1:]) != 1 {
+				return fmt.Errorf("incorrect param count to 'align'")
+			}
+			align, err := ParseAlignment(cmdParts[1])
+			if err != nil {
+				return err
+			}
+			textSettings.Align = align
+			continue
+		case "wrap":
+			if len(cmdParts[1:]) != 1 {
+				return fmt.Errorf("incorrect param count to 'wrap'")
+			}
+			width, err := ParseLength(cmdParts[1])
+			if err != nil {
+				return err
+			}
+			textSettings.Width = width
+			continue
+		case "spacing":
+			if len(cmdParts[1:]) != 1 && len(cmdParts[1:]) != 2 {
+				return fmt.Errorf("incorrect param count to 'spacing'")
+			}
+			letterSpacing, err := ParseLength(cmdParts[1])
+			if err != nil {
+				return err
+			}
+			textSettings.LetterSpacing = letterSpacing
+			if len(cmdParts) == 3 {
+				lineSpacing, err := strconv.ParseFloat(cmdParts[2], 64)
+				if err != nil {
+					return fmt.Errorf("invalid param to 'spacing': %s", err)
+				}
+				textSettings.LineSpacing = lineSpacing
+			}
+			continue
+		case "fonts":
+			for _, name := range Fonts.Names() {
+				if name == activeFont {
+					fmt.Println("*", name)
+				} else {
+					fmt.Println(" ", name)
+				}
+			}
+			continue
+		case "hatch":
+			// hatch <spacing> [angle in degrees] fills in outline glyphs, and hatch 0 turns it off
+			if len(cmdParts[1:]) != 1 && len(cmdParts[1:]) != 2 {
+				return fmt.Errorf("incorrect param count to 'hatch'")
+			}
+			spacing, err := ParseLength(cmdParts[1])
+			if err != nil {
+				return err
+			}
+			textSettings.Hatch = spacing
+			if len(cmdParts) == 3 {
+				degrees, err := strconv.ParseFloat(cmdParts[2], 64)
+				if err != nil {
+					return fmt.Errorf("invalid param to 'hatch': %s", err)
+				}
+				textSettings.HatchAngle = degrees * math.Pi / 180
+			}
+			continue
+		case "handwriting":
+			// handwriting <seed> [amount] varies glyphs like handwriting, and handwriting off turns it off
+			if len(cmdParts[1:]) != 1 && len(cmdParts[1:]) != 2 {
+				return fmt.Errorf("incorrect param count to 'handwriting'")
+			}
+			alternates := textSettings.Variation.Alternates
+			if cmdParts[1] == "off" {
+				textSettings.Variation = TextVariation{Alternates: alternates}
+				continue
+			}
+			seed, err := strconv.ParseInt(cmdParts[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid param to 'handwriting': %s", err)
+			}
+			amount := float64(1)
+			if len(cmdParts) == 3 {
+				if amount, err = strconv.ParseFloat(cmdParts[2], 64); err != nil {
+					return fmt.Errorf("invalid param to 'handwriting': %s", err)
+				}
+			}
+			textSettings.Variation = DefaultHandwriting.Scaled(amount)
+			textSettings.Variation.Seed = seed
+			textSettings.Variation.Alternates = alternates
+			continue
+		case "alternates":
+			// alternates <font>... sets the fonts that handwriting may take glyphs from, and alternates none clears them
+			if len(cmdParts[1:]) < 1 {
+				return fmt.Errorf("incorrect param count to 'alternates'")
+			}
+			var alternates []Font
+			if cmdParts[1] != "none" {
+				for _, name := range cmdParts[1:] {
+					font, err := Fonts.Lookup(name)
+					if err != nil {
+						return err
+					}
+					alternates = append(alternates, font)
+				}
+			}
+			textSettings.Variation.Alternates = alternates
+			continue
+		case "fallback":
+			// fallback <font> [greek] adds a font to the end of the fallback chain, and fallback none clears it
+			if len(cmdParts[1:]) != 1 && len(cmdParts[1:]) != 2 {
+				return fmt.Errorf("incorrect param count to 'fallback'")
+			}
+			if cmdParts[1] == "none" {
+				textSettings.Fallbacks = nil
+				continue
+			}
+			var mapping string
+			if len(cmdParts) == 3 {
+				mapping = cmdParts[2]
+			}
+			fallback, err := ParseFallback(cmdParts[1], mapping)
+			if err != nil {
+				return err
+			}
+			textSettings.Fallbacks = append(textSettings.Fallbacks, fallback)
+			continue
+		case "plot":
+			if len(cmdParts[1:]) < 1 {
+				return fmt.Errorf("incorrect param count to 'plot'")
+			}
+			d, err := textDrawing(textArg(rawParts[1:]))
+			if err != nil {
+				return err
+			}
+			resetSimulation(cmdr)
+			if err := PlotDrawing(cmdr, d, opts); err != nil {
+				return err
+			}
+			if err := reportSimulation(cmdr); err != nil {
+				return err
+			}
+			continue
+		case "circle", "arc", "curve":
+			path, err := curvePath(cmdParts[0], cmdParts[1:], *curveTolerance)
+			if err != nil {
+				return err
+			}
+			resetSimulation(cmdr)
+			if err := PlotDrawing(cmdr, newDrawing([]Path{path}), opts); err != nil {
+				return err
+			}
+			if err := reportSimulation(cmdr); err != nil {
+				return err
+			}
+			continue
+		case "textpath":
+			d, err := pathTextDrawing(rawParts[1:], *curveTolerance)
+			if err != nil {
+				return err
+			}
+			resetSimulation(cmdr)
+			if err := PlotDrawing(cmdr, d, opts); err != nil {
+				return err
+			}
+			if err := reportSimulation(cmdr); err != nil {
+				return err
+			}
+			continue
+		case "measure":
+			if len(cmdParts[1:]) < 1 {
+				return fmt.Errorf("incorrect param count to 'measure'")
+			}
+			layout, err := activeLayout()
+			if err != nil {
+				return err
+			}
+			fmt.Println(layout.Measure(textArg(rawParts[1:])))
+			continue
+		case "fit":
+			// fit <width> <text> sets the text size so that the text is the given width
+			if len(cmdParts[1:]) < 2 {
+				return fmt.Errorf("incorrect param count to 'fit'")
+			}
+			width, err := ParseLength(cmdParts[1])
+			if err != nil {
+				return err
+			}
+			layout, err := activeLayout()
+			if err != nil {
+				return err
+			}
+			size := layout.FitToWidth(textArg(rawParts[2:]), width)
+			if size <= 0 {
+				return fmt.Errorf("text can't be fit to %s", cmdParts[1])
+			}
+			textSettings.Size = size
+			fmt.Printf("size set to %.3fin (%.1fpt)\n", size, size*pointsPerInch)
+			continue
+		case "specimen":
+			// specimen [font] [first-last] [file.png] plots a sheet of the glyphs in the font, or writes
+			// it to a PNG. The range is of hex character codes, such as U+0041-U+005A, and defaults to
+			// every glyph, or printable ASCII for outline fonts.
+			if len(cmdParts[1:]) > 3 {
+				return fmt.Errorf("incorrect param count to 'specimen'")
+			}
+			specimenOpts := DefaultSpecimenOptions
+			fontName, filename := activeFont, ""
+			for _, arg := range rawParts[1:] {
+				if strings.EqualFold(filepath.Ext(arg), ".png") {
+					filename = arg
+				} else if first, last, ok := parseRuneRange(arg); ok {
+					specimenOpts.First, specimenOpts.Last = first, last
+				} else {
+					fontName = arg
+				}
+			}
+			font, err := Fonts.Lookup(fontName)
+			if err != nil {
+				return err
+			}
+			specimenOpts.Title = strings.ToLower(fontName)
+			d := FontSpecimen(font, specimenOpts)
+			if filename == "" {
+				resetSimulation(cmdr)
+				if err := PlotDrawing(cmdr, d, opts); err != nil {
+					return err
+				}
+				if err := reportSimulation(cmdr); err != nil {
+					return err
+				}
+				continue
+			}
+			encoded, err := d.RenderScaled(previewScale)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filename, encoded.Bytes(), 0644); err != nil {
+				return err
+			}
+			continue
+		case "stats":
+			if len(cmdParts[1:]) < 1 {
+				return fmt.Errorf("incorrect param count to 'stats'")
+			}
+			d, err := textDrawing(textArg(rawParts[1:]))
+			if err != nil {
+				return err
+			}
+			d = d.Simplify(opts.Simplify)
+			fmt.Println(d.EstimateStats(opts.Profiles))
+			continue
+		case "validate":
+			if len(cmdParts[1:]) < 1 {
+				return fmt.Errorf("incorrect param count to 'validate'")
+			}
+			d, err := textDrawing(textArg(rawParts[1:]))
+			if err != nil {
+				return err
+			}
+			d = d.Simplify(opts.Simplify)
+			fmt.Print(d.ValidatePlans(opts.Profiles, d.Plans(opts.Profiles)))
+			continue
+		case "preview":
+			if len(cmdParts[1:]) < 1 {
+				return fmt.Errorf("incorrect param count to 'preview'")
+			}
+			d, err := textDrawing(textArg(rawParts[1:]))
+			if err != nil {
+				return err
+			}
+			d = d.Simplify(opts.Simplify)
+			encoded, err := d.RenderVelocity(opts.Profiles, previewScale)
+			if err != nil {
+				return err
+			}
+			if err := imgcat(encoded, os.Stdout); err != nil {
+				return err
+			}
+			continue
+		case "export":
+			if len(cmdParts[1:]) < 3 {
+				return fmt.Errorf("incorrect param count to 'export'")
+			}
+			format, filename := cmdParts[1], rawParts[2]
+			d, err := textDrawing(textArg(rawParts[3:]))
+			if err != nil {
+				return err
+			}
+			d = d.Simplify(opts.Simplify)
+			write := WritePlansCSV
+			switch format {
+			case "csv":
+			case "json":
+				write = WritePlansJSON
+			default:
+				return fmt.Errorf("unknown export format: %s", format)
+			}
+			f, err := os.Create(filename)
+			if err != nil {
+				return err
+			}
+			if err := write(f, d, d.Plans(opts.Profiles)); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			continue
+		case "resume":
+			if rc, ok := cmdr.(interface{ Reconnect() error }); ok {
+				if err := rc.Reconnect(); err != nil {
+					return fmt.Errorf("failed to reconnect: %w", err)
+				}
+			}
+			resetSimulation(cmdr)
+			if err := ResumeDrawing(cmdr, opts); err != nil {
+				return err
+			}
+			if err := reportSimulation(cmdr); err != nil {
+				return err
+			}
 			continue
 		default:
 			log.Printf("unknown command: %s", cmdParts[0])
@@ -145,40 +532,26 @@ func readEvalPrint(input string, cmdr Commander) error {
 	return nil
 }
 
-func PlotDrawing(d Drawing) {
-	timesliceMs := 10
-	stepMs := timesliceMs
-	_ = stepMs
-	//stepSec := float64(stepMs) / 1000
-
-	for _, path := range d.paths {
-		plan := makePlan(
-			path.Path,
-			float64(16), //accel
-			0.001,       //corner factor
-			false,
-		)
-		t := 0
-		for t < int(plan.totalTime) {
-			break
-		}
+// resetSimulation clears what the simulator has recorded before a plot, so
+// that the report afterwards covers just that plot.
+func resetSimulation(cmdr Commander) {
+	if sim, ok := cmdr.(*Simulator); ok {
+		sim.Reset()
 	}
-	/*
-	   step_ms = TIMESLICE_MS
-	   step_s = step_ms / 1000
-	   t = 0
-	   while t < plan.t:
-	       i1 = plan.instant(t)
-	       i2 = plan.instant(t + step_s)
-	       d = i2.p.sub(i1.p)
-	       ex, ey = self.error
-	       ex, sx = modf(d.x * self.steps_per_unit + ex)
-	       ey, sy = modf(d.y * self.steps_per_unit + ey)
-	       self.error = ex, ey
-	       self.stepper_move(step_ms, int(sx), int(sy))
-	       t += step_s
-	   # self.wait()
-	*/
+}
+
+// reportSimulation prints the report and rendered result of a dry run, if cmdr is a simulator.
+func reportSimulation(cmdr Commander) error {
+	sim, ok := cmdr.(*Simulator)
+	if !ok {
+		return nil
+	}
+	fmt.Print(sim.Report())
+	encoded, err := sim.Render()
+	if err != nil {
+		return err
+	}
+	return imgcat(encoded, os.Stdout)
 }
 
 func newDrawing(paths []Path) Drawing {
@@ -206,11 +579,18 @@ func newDrawing(paths []Path) Drawing {
 }
 
 func main() {
-	dev, err := OpenDevice()
-	if err != nil {
-		log.Fatalf("failed to open device: %s", err)
+	flag.Parse()
+
+	var commander Commander
+	if *dryRun {
+		commander = NewSimulator()
+	} else {
+		dev, err := OpenDevice()
+		if err != nil {
+			log.Fatalf("failed to open device: %s", err)
+		}
+		commander = &deviceCommander{dev}
 	}
-	_ = dev
 
 	// c := make(chan os.Signal, 1)
 	// signal.Notify(c, os.Interrupt)
@@ -224,47 +604,292 @@ func main() {
 	// 	}
 	// }()
 
-	rl, err := readline.New("> ")
+	if err := selectFont(*fontName); err != nil {
+		log.Fatal(err)
+	}
+
+	if *curveTolerance <= 0 {
+		log.Fatalf("curve tolerance must be positive, got %g", *curveTolerance)
+	}
+
+	method, err := ParseSimplifyMethod(*simplifyMethod)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctl := NewPlotControl()
+	opts := PlotOptions{
+		Control:     ctl,
+		OnStatus:    NewProgressDisplay(os.Stdout),
+		Profiles:    MotionProfiles{PenUp: *penUpProfile, PenDown: *penDownProfile},
+		Simplify:    Simplification{method, *simplifyTolerance},
+		PlanWorkers: *planWorkers,
+		Validate:    *validatePlans,
+	}
+	// a simulated plot mustn't replace the checkpoint of a real one
+	if !*dryRun {
+		opts.Checkpoints = NewCheckpointStore(*checkpointDir)
+		if opts.Checkpoints.Exists() {
+			fmt.Println("found an unfinished plot, use 'resume' to continue it")
+			fmt.Println("if the plotter lost power, first move the carriage back by hand to where the plot started")
+		}
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:              "> ",
+		FuncFilterInputRune: plotKeys(ctl),
+	})
 	if err != nil {
 		log.Fatalf("failed to open readline: %s", err)
 	}
 	defer rl.Close()
 	readline.CaptureExitSignal(func() {
+		if ctl.Active() {
+			fmt.Println("captured exit signal, pausing plot")
+			ctl.Pause()
+			return
+		}
 		fmt.Println("captured exit signal!")
 	})
 
-	commander := &deviceCommander{dev}
+	// Keep reading input while commands run, so that keypresses can reach a
+	// plot in progress through plotKeys.
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := rl.Readline()
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	for line := range lines {
+		if err := readEvalPrint(line, commander, opts); err != nil {
+			log.Printf("error: %s", err)
+		}
+	}
+	commander.SteppersOff()
+}
+
+// plotKeys returns an input filter that, while a plot is in progress,
+// intercepts the keys for controlling it:
+// space or 'p' pauses and resumes, 'a' aborts, and ctrl-c pauses.
+func plotKeys(ctl *PlotControl) func(rune) (rune, bool) {
+	return func(r rune) (rune, bool) {
+		if !ctl.Active() {
+			return r, true
+		}
+		switch r {
+		case ' ', 'p':
+			ctl.Toggle()
+		case 'a':
+			ctl.Abort()
+		case readline.CharInterrupt:
+			ctl.Pause()
+		default:
+			return r, true
+		}
+		return r, false
+	}
+}
 
-	for {
-		line, err := rl.Readline()
+// curvePath flattens the curve described by the arguments to one of the
+// curve commands to within tolerance. The arguments are all in inches, with
+// angles in degrees:
+//
+//	circle <x> <y> <radius>
+//	arc <x> <y> <radius> <start angle> <sweep angle>
+//	curve <x0> <y0> <x1> <y1> <x2> <y2> [<x3> <y3>]
+func curvePath(name string, args []string, tolerance float64) (Path, error) {
+	values := make([]float64, 0, len(args))
+	for _, arg := range args {
+		v, err := strconv.ParseFloat(arg, 64)
 		if err != nil {
-			commander.SteppersOff()
+			return nil, fmt.Errorf("invalid param to '%s': %s", name, err)
+		}
+		values = append(values, v)
+	}
+	radians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+	vec := func(i int) Vec2d {
+		return Vec2d{values[i], values[i+1]}
+	}
+
+	switch {
+	case name == "circle" && len(values) == 3:
+		return Circle(vec(0), values[2]).Flatten(tolerance), nil
+	case name == "arc" && len(values) == 5:
+		return NewArc(vec(0), values[2], radians(values[3]), radians(values[4])).Flatten(tolerance), nil
+	case name == "curve" && len(values) == 6:
+		return QuadraticBezier{vec(0), vec(2), vec(4)}.Flatten(tolerance), nil
+	case name == "curve" && len(values) == 8:
+		return CubicBezier{vec(0), vec(2), vec(4), vec(6)}.Flatten(tolerance), nil
+	}
+	return nil, fmt.Errorf("incorrect param count to '%s'", name)
+}
+
+// pathTextDrawing sets text along a curve, flattened to within tolerance,
+// with the current text settings, from the arguments to the textpath command:
+//
+//	textpath [inside] [offset <length>] <curve command> -- <text>
+//
+// where the curve command is any of those accepted by curvePath. For
+// example, "textpath arc 4 4 2 180 180 -- text" with centered alignment
+// puts the text around the top of a circle.
+func pathTextDrawing(args []string, tolerance float64) (Drawing, error) {
+	separator := -1
+	for i, arg := range args {
+		if arg == "--" {
+			separator = i
 			break
 		}
-		if err := readEvalPrint(line, commander); err != nil {
-			log.Printf("error: %s", err)
+	}
+	if separator < 0 || separator == len(args)-1 {
+		return Drawing{}, fmt.Errorf("incorrect params to 'textpath'")
+	}
+	text := textArg(args[separator+1:])
+	// only the text keeps its case
+	args = strings.Fields(strings.ToLower(strings.Join(args[:separator], " ")))
+
+	var opts PathTextOptions
+	for len(args) > 0 {
+		if args[0] == "inside" {
+			opts.Inside = true
+			args = args[1:]
+		} else if args[0] == "offset" && len(args) > 1 {
+			offset, err := ParseLength(args[1])
+			if err != nil {
+				return Drawing{}, err
+			}
+			opts.Offset = offset
+			args = args[2:]
+		} else {
+			break
+		}
+	}
+	if len(args) == 0 {
+		return Drawing{}, fmt.Errorf("incorrect params to 'textpath'")
+	}
+	baseline, err := curvePath(args[0], args[1:], tolerance)
+	if err != nil {
+		return Drawing{}, err
+	}
+	layout, err := activeLayout()
+	if err != nil {
+		return Drawing{}, err
+	}
+	return layout.AlongPath(text, baseline, opts), nil
+}
+
+// selectFont makes the named font the active one. If there's no font with
+// that name, it's loaded from the font file (Hershey .jhf, SVG, TrueType or
+// OpenType) with that name, or failing that from the installed TrueType or
+// OpenType font with that name, and registered under its name first.
+func selectFont(name string) error {
+	if _, err := Fonts.Lookup(name); err == nil {
+		activeFont = strings.ToLower(name)
+		return nil
+	}
+	font, err := LoadFontFile(name)
+	if err != nil {
+		_, supported := fontLoaders[strings.ToLower(filepath.Ext(name))]
+		if supported && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to load font: %w", err)
 		}
+		// only search the installed fonts once the name is known not to be a font file
+		installed, findErr := FindInstalledFont(name)
+		if findErr != nil {
+			return fmt.Errorf("unknown font: %s isn't registered, a font file, or an installed font", name)
+		}
+		if font, err = LoadFontFile(installed); err != nil {
+			return fmt.Errorf("failed to load font: %w", err)
+		}
+		name = installed
+	}
+	fontName := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	RegisterFont(fontName, font)
+	activeFont = strings.ToLower(fontName)
+	fmt.Printf("loaded %d glyphs from %s as %s\n", font.count(), name, activeFont)
+	return nil
+}
+
+// parseRuneRange parses a range of hex character codes such as
+// U+0041-U+005A, where the U+ prefixes are optional.
+func parseRuneRange(s string) (first, last rune, ok bool) {
+	from, to, found := strings.Cut(s, "-")
+	if !found {
+		return 0, 0, false
+	}
+	parse := func(code string) (rune, bool) {
+		code = strings.TrimPrefix(strings.ToUpper(code), "U+")
+		n, err := strconv.ParseUint(code, 16, 32)
+		return rune(n), err == nil && n <= unicode.MaxRune
+	}
+	first, okFirst := parse(from)
+	last, okLast := parse(to)
+	if !okFirst || !okLast || first > last {
+		return 0, 0, false
 	}
+	return first, last, true
 }
 
-func text(input string, font Font) []Path {
+// textArg joins the words of a command's text argument back together,
+// turning each literal "\n" into a line break.
+func textArg(words []string) string {
+	return strings.ReplaceAll(strings.Join(words, " "), `\n`, "\n")
+}
+
+// activeLayout returns the current text settings with the active font.
+func activeLayout() (TextLayout, error) {
+	font, err := Fonts.Lookup(activeFont)
+	if err != nil {
+		return TextLayout{}, err
+	}
+	layout := textSettings
+	layout.Font = font
+	return layout, nil
+}
+
+// textDrawing lays out the input in the active font with the current text settings.
+func textDrawing(input string) (Drawing, error) {
+	layout, err := activeLayout()
+	if err != nil {
+		return Drawing{}, err
+	}
+	return layout.Layout(input), nil
+}
+
+// text lays out the input in the named font from the Fonts registry, in font units.
+func text(input string, fontName string) ([]Path, error) {
+	font, err := Fonts.Lookup(fontName)
+	if err != nil {
+		return nil, err
+	}
+	return font.text(input), nil
+}
+
+// text lays out the input in font units, drawing characters that the font
+// doesn't have as missingGlyph and applying the font's kerning.
+func (font Font) text(input string) []Path {
 	const spacing = 0
 	var out []Path
-	x := 0
+	var x float64
+	var prev rune
 	for _, ch := range input {
-		index := int(ch) - 32
-		if index < 0 || index > len(font) {
-			x += spacing
-			continue
-		}
-		glyph := font[index]
+		x += font.kern(prev, ch)
+		prev = ch
+		glyph := glyphFor(ch, font, nil)
 		for _, path := range glyph.paths {
 			var newPath = make(Path, 0, len(path))
 			for _, point := range path {
 				newPath = append(
 					newPath,
 					Vec2d{
-						x: float64(x) + point.x - float64(glyph.left),
+						x: x + point.x - glyph.left,
 						y: point.y,
 					})
 			}
@@ -281,40 +906,97 @@ type Drawing struct {
 }
 
 type DrawingStats struct {
+	// UpLength is the pen-up travel, including the travel from the origin to the first path.
 	UpLength   float64
 	DownLength float64
+
+	// UpTime and DownTime are the planned motion times for the pen-up and
+	// pen-down paths, covering the same moves as UpLength and DownLength.
+	UpTime    time.Duration
+	DownTime  time.Duration
+	TotalTime time.Duration
+
+	// PenLifts is how many times the pen is lifted, to travel between paths
+	// and once the last path has been drawn, as a plot lifts it.
+	PenLifts  int
+	PathCount int
+
+	// TopLeft and BottomRight are the corners of the box bounding the pen-down paths,
+	// and Width and Height are its size in inches.
+	TopLeft, BottomRight Vec2d
+	Width, Height        float64
+}
+
+func (s DrawingStats) String() string {
+	return fmt.Sprintf(
+		"%d paths, %d pen lifts, %.2fin x %.2fin, drawing %.2fin in %s, travelling %.2fin in %s, total time %s",
+		s.PathCount,
+		s.PenLifts,
+		s.Width,
+		s.Height,
+		s.DownLength,
+		s.DownTime.Round(time.Second),
+		s.UpLength,
+		s.UpTime.Round(time.Second),
+		s.TotalTime.Round(time.Second),
+	)
 }
 
+// Stats returns the stats of the drawing, with times planned using DefaultMotionProfiles.
 func (d Drawing) Stats() DrawingStats {
-	var upLength, downLength float64
+	return d.EstimateStats(DefaultMotionProfiles)
+}
+
+// EstimateStats returns the stats of the drawing, with times planned using the given motion profiles.
+func (d Drawing) EstimateStats(profiles MotionProfiles) DrawingStats {
+	var stats DrawingStats
+	var upTime, downTime float64
+	first := true
+	plans := d.Plans(profiles)
 	for i, path := range d.paths {
+		if !path.penUp && (i+1 == len(d.paths) || d.paths[i+1].penUp) {
+			stats.PenLifts++
+		}
 		if len(path.Path) <= 1 {
 			continue
 		}
-		var length float64
-		for i := 1; i < len(path.Path); i++ {
-			p0, p1 := path.Path[i-1], path.Path[i]
-			length += math.Hypot(p1.x-p0.x, p1.y-p0.y)
-		}
+		plan := plans[i]
 		if path.penUp {
-			if i == 0 {
-				continue
-			}
-			upLength += length
+			upTime += plan.totalTime
+			stats.UpLength += plan.totalLength
 			continue
 		}
-		downLength += length
-	}
-	return DrawingStats{
-		UpLength:   upLength,
-		DownLength: downLength,
+		downTime += plan.totalTime
+		stats.DownLength += plan.totalLength
+		stats.PathCount++
+		for _, point := range path.Path {
+			if first {
+				stats.TopLeft, stats.BottomRight = point, point
+				first = false
+			}
+			stats.TopLeft.x = math.Min(stats.TopLeft.x, point.x)
+			stats.TopLeft.y = math.Min(stats.TopLeft.y, point.y)
+			stats.BottomRight.x = math.Max(stats.BottomRight.x, point.x)
+			stats.BottomRight.y = math.Max(stats.BottomRight.y, point.y)
+		}
 	}
+	stats.UpTime = seconds(upTime)
+	stats.DownTime = seconds(downTime)
+	stats.TotalTime = stats.UpTime + stats.DownTime
+	stats.Width = stats.BottomRight.x - stats.TopLeft.x
+	stats.Height = stats.BottomRight.y - stats.TopLeft.y
+	return stats
 }
 
 func (d Drawing) Render() (*bytes.Buffer, error) {
+	return d.RenderScaled(1)
+}
+
+// RenderScaled renders the pen-down strokes of the drawing as a PNG, with scale pixels per drawing unit.
+func (d Drawing) RenderScaled(scale float64) (*bytes.Buffer, error) {
 	margin := float64(10) // TODO
 	topLeft, bottomRight := Bounds(d.paths)
-	dc := gg.NewContext(int(bottomRight.x-topLeft.x+2*margin), int(bottomRight.y-topLeft.y+2*margin))
+	dc := gg.NewContext(int((bottomRight.x-topLeft.x)*scale+2*margin), int((bottomRight.y-topLeft.y)*scale+2*margin))
 	translation := Vec2d{-1 * topLeft.x, -1 * topLeft.y}
 	dc.Clear()
 	dc.SetColor(color.White)
@@ -330,13 +1012,13 @@ func (d Drawing) Render() (*bytes.Buffer, error) {
 		for _, point := range p.Path {
 			if p.penUp {
 				dc.MoveTo(
-					point.x+translation.x+margin,
-					point.y+translation.y+margin,
+					(point.x+translation.x)*scale+margin,
+					(point.y+translation.y)*scale+margin,
 				)
 			} else {
 				dc.LineTo(
-					point.x+translation.x+margin,
-					point.y+translation.y+margin,
+					(point.x+translation.x)*scale+margin,
+					(point.y+translation.y)*scale+margin,
 				)
 			}
 		}
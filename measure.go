@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TextMetrics is the size of a line of text, in inches. Positions are
+// relative to the start of the line's baseline, with y increasing down the
+// page as it does everywhere else.
+type TextMetrics struct {
+	// Advance is how far along the line the text reaches, which is the
+	// width that TextLayout wraps and aligns lines to.
+	Advance float64
+	// Ascent and Descent are how far the glyphs reach above and below the
+	// baseline, from the points that are drawn rather than nominal font metrics.
+	Ascent, Descent float64
+	// Min and Max are the corners of the tight bounding box around the
+	// glyphs. They're both zero if nothing is drawn.
+	Min, Max Vec2d
+}
+
+func (m TextMetrics) String() string {
+	return fmt.Sprintf(
+		"advance %.3fin, ascent %.3fin, descent %.3fin, bounds %.3fin x %.3fin from (%.3f, %.3f) to (%.3f, %.3f)",
+		m.Advance, m.Ascent, m.Descent, m.Max.x-m.Min.x, m.Max.y-m.Min.y, m.Min.x, m.Min.y, m.Max.x, m.Max.y,
+	)
+}
+
+// Measure returns the size of the input set as a single line in the
+// layout's font, size and letter spacing. Newlines are measured as spaces.
+func (l TextLayout) Measure(input string) TextMetrics {
+	scale := l.Size / hersheyUnitsPerEm
+	input = strings.ReplaceAll(input, "\n", " ")
+	min, max := Vec2d{math.Inf(1), math.Inf(1)}, Vec2d{math.Inf(-1), math.Inf(-1)}
+	var x float64
+	var prev rune
+	var prevSource int
+	index := 0
+	for _, ch := range input {
+		glyph, source := l.glyphAt(ch, index)
+		x += l.kern(prev, ch, prevSource, source) * scale
+		prev, prevSource = ch, source
+		index++
+		for _, path := range glyph.paths {
+			for _, point := range path {
+				p := Vec2d{x + (point.x-glyph.left)*scale, (point.y - hersheyBaseline) * scale}
+				min = Vec2d{math.Min(min.x, p.x), math.Min(min.y, p.y)}
+				max = Vec2d{math.Max(max.x, p.x), math.Max(max.y, p.y)}
+			}
+		}
+		x += l.advance(glyph, scale)
+	}
+
+	m := TextMetrics{Advance: l.measure(input, 0, scale)}
+	if math.IsInf(min.x, 1) {
+		return m
+	}
+	m.Min, m.Max = min, max
+	m.Ascent, m.Descent = math.Max(0, -min.y), math.Max(0, max.y)
+	return m
+}
+
+// FitToWidth returns the size at which the longest line of the input has
+// the given advance, with the layout's letter spacing kept as it is. It
+// returns zero if the letter spacing alone is wider than that.
+func (l TextLayout) FitToWidth(input string, width float64) float64 {
+	// the advance of a line grows with the size, apart from the fixed
+	// spacing between its letters, so one measurement at unit size is enough
+	unit := l
+	unit.Size, unit.LetterSpacing = 1, 0
+	size := math.Inf(1)
+	for _, line := range strings.Split(input, "\n") {
+		advance := unit.Measure(line).Advance
+		if advance <= 0 {
+			continue
+		}
+		spacing := l.LetterSpacing * float64(len([]rune(line))-1)
+		size = math.Min(size, (width-spacing)/advance)
+	}
+	if math.IsInf(size, 1) || size < 0 {
+		return 0
+	}
+	return size
+}
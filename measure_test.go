@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// measureFont has an "a" that reaches from the top of the em square to the
+// baseline, and kerns pairs of them together.
+var measureFont = Font{
+	runes: map[rune]Glyph{
+		' ': {left: -5, right: 5},
+		'a': {left: -4, right: 4, paths: []Path{{{-4, -7}, {4, 9}}}},
+	},
+	kerning: map[[2]rune]float64{{'a', 'a'}: -2},
+}
+
+func TestMeasure(t *testing.T) {
+	// at a size of one em, inches are font units
+	layout := TextLayout{Font: measureFont, Size: hersheyUnitsPerEm}
+	tests := []struct {
+		input         string
+		letterSpacing float64
+		want          TextMetrics
+	}{
+		{"", 0, TextMetrics{}},
+		{" ", 0, TextMetrics{Advance: 10}},
+		{"a", 0, TextMetrics{Advance: 8, Ascent: 16, Min: Vec2d{0, -16}, Max: Vec2d{8, 0}}},
+		{"aa", 0, TextMetrics{Advance: 14, Ascent: 16, Min: Vec2d{0, -16}, Max: Vec2d{14, 0}}},
+		{"aa", 1, TextMetrics{Advance: 15, Ascent: 16, Min: Vec2d{0, -16}, Max: Vec2d{15, 0}}},
+		{"a\na", 0, TextMetrics{Advance: 26, Ascent: 16, Min: Vec2d{0, -16}, Max: Vec2d{26, 0}}},
+	}
+	for _, test := range tests {
+		layout.LetterSpacing = test.letterSpacing
+		if got := layout.Measure(test.input); got != test.want {
+			t.Errorf("Measure(%q) with letter spacing %v = %+v, want %+v", test.input, test.letterSpacing, got, test.want)
+		}
+	}
+
+	layout = TextLayout{Font: measureFont, Size: 1}
+	if got, want := layout.Measure("aa").Advance, 14.0/hersheyUnitsPerEm; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Measure(\"aa\") at one inch has an advance of %v, want %v", got, want)
+	}
+}
+
+func TestFitToWidth(t *testing.T) {
+	tests := []struct {
+		input                string
+		letterSpacing, width float64
+		want                 float64
+	}{
+		{"aa", 0, 28, 64},
+		{"aa", 2, 28, 26 * hersheyUnitsPerEm / 14.0},
+		// the longest line sets the size
+		{"a\naa", 0, 28, 64},
+		// the letter spacing alone is wider than that
+		{"aa", 2, 1, 0},
+		{"", 0, 28, 0},
+	}
+	for _, test := range tests {
+		layout := TextLayout{Font: measureFont, Size: 5, LetterSpacing: test.letterSpacing}
+		size := layout.FitToWidth(test.input, test.width)
+		if math.Abs(size-test.want) > 1e-9 {
+			t.Errorf("FitToWidth(%q, %v) with letter spacing %v = %v, want %v", test.input, test.width, test.letterSpacing, size, test.want)
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+		// the text measures the width it was fitted to
+		layout.Size = size
+		if advance := layout.Measure("aa").Advance; math.Abs(advance-test.width) > 1e-9 {
+			t.Errorf("%q fitted to %v measures %v", test.input, test.width, advance)
+		}
+	}
+}
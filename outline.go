@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// outlineMaxRune is the last character that outline fonts are searched for
+// glyphs up to, which covers the Basic Multilingual Plane.
+const outlineMaxRune = 0xffff
+
+// outlineFont loads the glyphs of a TrueType or OpenType font as they're
+// first needed, and keeps them for later.
+type outlineFont struct {
+	font *sfnt.Font
+	// chars holds every character the font has a glyph for, in order.
+	chars []rune
+	ppem  fixed.Int26_6
+	scale float64
+
+	mu      sync.Mutex
+	buf     sfnt.Buffer
+	glyphs  map[rune]Glyph
+	kerning map[[2]rune]float64
+}
+
+// LoadOutlineFont reads a TrueType or OpenType font, whose glyph outlines
+// are converted into closed paths as they're needed. Like SVG fonts, the
+// glyphs are scaled to the size of a Hershey font's em square, with the
+// baseline where Hershey fonts put it. The outlines are plotted as they
+// are; set TextLayout.Hatch to fill them in.
+func LoadOutlineFont(r io.Reader) (Font, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Font{}, err
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return Font{}, err
+	}
+	unitsPerEm := f.UnitsPerEm()
+	out := &outlineFont{
+		font: f,
+		// loading glyphs at a size of one pixel per font unit gives their outlines in font units
+		ppem:    fixed.I(int(unitsPerEm)),
+		scale:   hersheyUnitsPerEm / float64(unitsPerEm),
+		glyphs:  map[rune]Glyph{},
+		kerning: map[[2]rune]float64{},
+	}
+	for ch := rune(' '); ch <= outlineMaxRune; ch++ {
+		if index, err := f.GlyphIndex(&out.buf, ch); err == nil && index != 0 {
+			out.chars = append(out.chars, ch)
+		}
+	}
+	if len(out.chars) == 0 {
+		return Font{}, errors.New("font has no glyphs for any characters")
+	}
+	return Font{outline: out}, nil
+}
+
+// glyph returns the glyph for a character, loading it the first time it's
+// wanted. A glyph that can't be loaded is treated as missing.
+func (o *outlineFont) glyph(ch rune) (Glyph, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if glyph, ok := o.glyphs[ch]; ok {
+		return glyph, true
+	}
+	index, err := o.font.GlyphIndex(&o.buf, ch)
+	if err != nil || index == 0 {
+		return Glyph{}, false
+	}
+	segments, err := o.font.LoadGlyph(&o.buf, index, o.ppem, nil)
+	if err != nil {
+		return Glyph{}, false
+	}
+	advance, err := o.font.GlyphAdvance(&o.buf, index, o.ppem, font.HintingNone)
+	if err != nil {
+		return Glyph{}, false
+	}
+	glyph := Glyph{left: 0, right: float64(advance) / 64 * o.scale, paths: outlinePaths(segments, o.transform)}
+	o.glyphs[ch] = glyph
+	return glyph, true
+}
+
+// kern returns the adjustment to the advance from one character to the
+// next, reading it from the font the first time the pair is wanted.
+func (o *outlineFont) kern(a, b rune) float64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	pair := [2]rune{a, b}
+	if kern, ok := o.kerning[pair]; ok {
+		return kern
+	}
+	var kern float64
+	ia, errA := o.font.GlyphIndex(&o.buf, a)
+	ib, errB := o.font.GlyphIndex(&o.buf, b)
+	if errA == nil && errB == nil && ia != 0 && ib != 0 {
+		if k, err := o.font.Kern(&o.buf, ia, ib, o.ppem, font.HintingNone); err == nil {
+			kern = float64(k) / 64 * o.scale
+		}
+	}
+	o.kerning[pair] = kern
+	return kern
+}
+
+// transform converts a point of a glyph outline from font units to Hershey
+// units. sfnt already gives outlines with y running down the page, as
+// drawings have it, so only the baseline has to be moved.
+func (o *outlineFont) transform(p fixed.Point26_6) Vec2d {
+	return Vec2d{float64(p.X) / 64 * o.scale, hersheyBaseline + float64(p.Y)/64*o.scale}
+}
+
+// outlinePaths converts the segments of a glyph outline into a closed path
+// for each of its contours, flattening curves finely enough for text an inch high.
+func outlinePaths(segments sfnt.Segments, transform func(fixed.Point26_6) Vec2d) []Path {
+	tolerance := DefaultCurveTolerance * hersheyUnitsPerEm
+	var paths []Path
+	var path Path
+	finish := func() {
+		if len(path) > 1 {
+			if path[0] != path[len(path)-1] {
+				path = append(path, path[0])
+			}
+			paths = append(paths, path)
+		}
+		path = nil
+	}
+	for _, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			finish()
+			path = Path{transform(s.Args[0])}
+		case sfnt.SegmentOpLineTo:
+			path = append(path, transform(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			curve := QuadraticBezier{path[len(path)-1], transform(s.Args[0]), transform(s.Args[1])}
+			path = append(path, curve.Flatten(tolerance)[1:]...)
+		case sfnt.SegmentOpCubeTo:
+			curve := CubicBezier{path[len(path)-1], transform(s.Args[0]), transform(s.Args[1]), transform(s.Args[2])}
+			path = append(path, curve.Flatten(tolerance)[1:]...)
+		}
+	}
+	finish()
+	return paths
+}
+
+// fontDirs returns the directories that fonts are usually installed in on this system.
+func fontDirs() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{filepath.Join(home, "Library", "Fonts"), "/Library/Fonts", "/System/Library/Fonts"}
+	case "windows":
+		return []string{filepath.Join(os.Getenv("WINDIR"), "Fonts"), filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Fonts")}
+	}
+	return []string{filepath.Join(home, ".local", "share", "fonts"), filepath.Join(home, ".fonts"), "/usr/local/share/fonts", "/usr/share/fonts"}
+}
+
+// FindInstalledFont returns the path of the installed TrueType or OpenType
+// font whose file name, without its extension, is the given name, ignoring case.
+func FindInstalledFont(name string) (string, error) {
+	var found string
+	errFound := errors.New("found")
+	for _, dir := range fontDirs() {
+		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				// skip anything that can't be read
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if d.IsDir() || ext != ".ttf" && ext != ".otf" {
+				return nil
+			}
+			if strings.EqualFold(strings.TrimSuffix(d.Name(), filepath.Ext(path)), name) {
+				found = path
+				return errFound
+			}
+			return nil
+		})
+		if err == errFound {
+			return found, nil
+		}
+	}
+	return "", fmt.Errorf("no installed font named %s", name)
+}
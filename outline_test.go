@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestLoadOutlineFont(t *testing.T) {
+	font, err := LoadOutlineFont(bytes.NewReader(goregular.TTF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.outline == nil || font.count() == 0 {
+		t.Fatalf("font has %d glyphs, want some", font.count())
+	}
+	// nothing is loaded until it's wanted
+	if n := len(font.outline.glyphs); n != 0 {
+		t.Errorf("%d glyphs were loaded up front, want 0", n)
+	}
+
+	o, ok := font.glyph('o')
+	if !ok || o.right <= 0 {
+		t.Fatalf("o = %+v, %v, want a glyph with an advance", o, ok)
+	}
+	// the outside and the counter of an o are both closed paths
+	if len(o.paths) != 2 || !o.paths[0].closed() || !o.paths[1].closed() {
+		t.Errorf("o has %d paths, want 2 closed ones", len(o.paths))
+	}
+	if n := len(font.outline.glyphs); n != 1 {
+		t.Errorf("%d glyphs are cached, want 1", n)
+	}
+	if again, _ := font.glyph('o'); &again.paths[0][0] != &o.paths[0][0] {
+		t.Error("o was loaded a second time instead of from the cache")
+	}
+	if len(Hatch(o.paths, 1, 0)) == 0 {
+		t.Error("hatching o drew nothing")
+	}
+
+	if _, ok := font.glyph(0x10ffff); ok {
+		t.Error("got a glyph for a character the font doesn't have")
+	}
+	font.kern('A', 'V')
+	if _, ok := font.outline.kerning[[2]rune{'A', 'V'}]; !ok {
+		t.Error("kerning wasn't cached")
+	}
+}
+
+func TestLoadOutlineFontErrors(t *testing.T) {
+	if _, err := LoadOutlineFont(bytes.NewReader([]byte("not a font"))); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// commandQueueSize is how many commands the plotter can get ahead of the device.
+	commandQueueSize = 64
+
+	// motionPollInterval is how often QM is sent while waiting for the motors to stop.
+	motionPollInterval = 10 * time.Millisecond
+)
+
+var errQueueClosed = errors.New("command queue closed")
+
+// queuedCommand is a command waiting to be sent to the device. Once it's
+// been sent, then (if set) is called to record its effect on the plot.
+type queuedCommand struct {
+	send func(Commander) error
+	then func()
+}
+
+// commandQueue sends the commands produced by the plotter from a separate
+// goroutine that owns the device, so that the next moves are already
+// waiting while the current ones run and the EBB's motion FIFO never runs
+// dry. The queue is bounded, so the plotter blocks once it gets too far
+// ahead. The device goroutine also polls the PRG button.
+type commandQueue struct {
+	cmdr     Commander
+	ctl      *PlotControl
+	commands chan queuedCommand
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	// done is closed when the device goroutine exits, after setting err if
+	// it stopped because a command failed.
+	done chan struct{}
+	err  error
+}
+
+func newCommandQueue(cmdr Commander, ctl *PlotControl) *commandQueue {
+	q := &commandQueue{
+		cmdr:     cmdr,
+		ctl:      ctl,
+		commands: make(chan queuedCommand, commandQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *commandQueue) run() {
+	defer close(q.done)
+	var poll <-chan time.Time
+	if q.ctl != nil {
+		ticker := time.NewTicker(buttonPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	for {
+		select {
+		case c := <-q.commands:
+			if c.send != nil {
+				if err := c.send(q.cmdr); err != nil {
+					q.err = err
+					return
+				}
+			}
+			if c.then != nil {
+				c.then()
+			}
+		case <-poll:
+			if err := q.pollButton(); err != nil {
+				q.err = err
+				return
+			}
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// pollButton toggles the pause state if the PRG button was pressed.
+func (q *commandQueue) pollButton() error {
+	pressed, err := q.cmdr.QueryButton()
+	if err != nil {
+		return err
+	}
+	if pressed {
+		q.ctl.Toggle()
+	}
+	return nil
+}
+
+// push adds a command to the queue, blocking while it's full. If the device
+// goroutine has stopped, it returns the error that stopped it instead.
+func (q *commandQueue) push(c queuedCommand) error {
+	select {
+	case q.commands <- c:
+		return nil
+	case <-q.done:
+		if q.err != nil {
+			return q.err
+		}
+		return errQueueClosed
+	}
+}
+
+// flush waits until every queued command has been sent and the EBB has
+// finished running them.
+func (q *commandQueue) flush() error {
+	flushed := make(chan struct{})
+	err := q.push(queuedCommand{
+		send: waitForMotion,
+		then: func() { close(flushed) },
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-q.done:
+		if q.err != nil {
+			return q.err
+		}
+		return errQueueClosed
+	}
+}
+
+// close stops the device goroutine, dropping any commands that haven't been
+// sent, and waits for it to exit.
+func (q *commandQueue) close() {
+	q.stopOnce.Do(func() { close(q.stop) })
+	<-q.done
+}
+
+// waitForMotion polls QM until the EBB's motion FIFO is empty and the motors have stopped.
+func waitForMotion(cmdr Commander) error {
+	for {
+		status, err := cmdr.QueryMotion()
+		if err != nil {
+			return err
+		}
+		if status.Idle() {
+			return nil
+		}
+		time.Sleep(motionPollInterval)
+	}
+}
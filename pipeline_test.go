@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// fakePort is a serial port that replies with canned responses and records what's written to it.
+type fakePort struct {
+	serial.Port
+	responses *strings.Reader
+	written   bytes.Buffer
+}
+
+func (p *fakePort) Read(b []byte) (int, error)  { return p.responses.Read(b) }
+func (p *fakePort) Write(b []byte) (int, error) { return p.written.Write(b) }
+
+func newFakeDevice(responses string) (*deviceCommander, *fakePort) {
+	port := &fakePort{responses: strings.NewReader(responses)}
+	return &deviceCommander{&Device{port: port, bufReader: *bufio.NewReader(port)}}, port
+}
+
+func TestQueryMotion(t *testing.T) {
+	dc, port := newFakeDevice("QM,1,1,0,1\n\rQM,0,0,0,0\n\rQM,0,0,0\n\rEBBv13_and_above EB Firmware Version 2.8.1\r\n")
+	want := []MotionStatus{
+		{Executing: true, Motor1: true, FIFO: true},
+		{},
+		// firmware before 2.4.4 doesn't report the FIFO
+		{},
+	}
+	for i, w := range want {
+		status, err := dc.QueryMotion()
+		if err != nil {
+			t.Fatalf("query %d: %v", i, err)
+		}
+		if status != w {
+			t.Errorf("query %d: got %+v, want %+v", i, status, w)
+		}
+	}
+	// the '\r' ending each QM response is consumed, so the next command reads its own response
+	if version, err := dc.Version(); err != nil || !strings.Contains(version, "Version 2.8.1") {
+		t.Errorf("read %q, %v after the QM responses", version, err)
+	}
+	if got, want := port.written.String(), "QM\rQM\rQM\rV\r"; got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+
+	// a response that's missing the '\r' leaves the next one intact
+	dc, _ = newFakeDevice("QM,0,0,0,0\nQM,1,0,0,0\n\r")
+	if _, err := dc.QueryMotion(); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := dc.QueryMotion(); err != nil || !status.Executing {
+		t.Errorf("got %+v, %v for the second response", status, err)
+	}
+
+	dc, _ = newFakeDevice("!8 Err: Unknown command\n\r")
+	if _, err := dc.QueryMotion(); err == nil {
+		t.Error("expected an error for a response that isn't from QM")
+	}
+}
+
+// recordingCommander records the commands the queue sends to it, blocking
+// moves while hold is locked and failing them once fail is set, which
+// failOnDown does as soon as the pen is lowered.
+type recordingCommander struct {
+	*Simulator
+	mu         sync.Mutex
+	sent       []string
+	hold       sync.Mutex
+	fail       error
+	failOnDown bool
+	query      error
+}
+
+func (r *recordingCommander) record(command string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, command)
+}
+
+func (r *recordingCommander) PenUp() error {
+	r.record("up")
+	return r.Simulator.PenUp()
+}
+
+func (r *recordingCommander) PenDown() error {
+	r.record("down")
+	if r.failOnDown {
+		r.fail = errInjected
+	}
+	return r.Simulator.PenDown()
+}
+
+func (r *recordingCommander) move() error {
+	r.hold.Lock()
+	defer r.hold.Unlock()
+	if r.fail != nil {
+		return r.fail
+	}
+	r.record("move")
+	return nil
+}
+
+func (r *recordingCommander) Move(stepsX, stepsY int, duration time.Duration) error {
+	if err := r.move(); err != nil {
+		return err
+	}
+	return r.Simulator.Move(stepsX, stepsY, duration)
+}
+
+func (r *recordingCommander) LowLevelMove(motor1, motor2 MotorMove) error {
+	if err := r.move(); err != nil {
+		return err
+	}
+	return r.Simulator.LowLevelMove(motor1, motor2)
+}
+
+func (r *recordingCommander) QueryMotion() (MotionStatus, error) {
+	return MotionStatus{}, r.query
+}
+
+func TestCommandQueue(t *testing.T) {
+	cmdr := &recordingCommander{Simulator: NewSimulator()}
+	q := newCommandQueue(cmdr, nil)
+	defer q.close()
+
+	// commands are sent in order, each followed by its then
+	var order []string
+	push := func(name string, send func(Commander) error) {
+		err := q.push(queuedCommand{send: send, then: func() { order = append(order, name) }})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	push("down", func(c Commander) error { return c.PenDown() })
+	push("move", func(c Commander) error { return c.Move(100, 0, time.Second) })
+	push("up", func(c Commander) error { return c.PenUp() })
+	if err := q.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"down", "move", "up"}; !reflect.DeepEqual(cmdr.sent, want) || !reflect.DeepEqual(order, want) {
+		t.Errorf("sent %v and recorded %v, want %v", cmdr.sent, order, want)
+	}
+
+	// the plotter blocks once it's a full queue ahead of the device
+	cmdr.hold.Lock()
+	move := queuedCommand{send: func(c Commander) error { return c.Move(1, 0, time.Millisecond) }}
+	pushed := make(chan int)
+	go func() {
+		n := 0
+		for ; n < 2*commandQueueSize; n++ {
+			if q.push(move) != nil {
+				break
+			}
+			if n == commandQueueSize+1 {
+				pushed <- n
+			}
+		}
+		pushed <- n
+	}()
+	select {
+	case n := <-pushed:
+		t.Errorf("pushed %d commands while the device was stalled", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// once a command fails, the error is returned to the plotter
+	cmdr.fail = errInjected
+	cmdr.hold.Unlock()
+	<-pushed
+	if err := q.push(move); !errors.Is(err, errInjected) {
+		t.Errorf("got %v from push after a failure, want the injected error", err)
+	}
+	if err := q.flush(); !errors.Is(err, errInjected) {
+		t.Errorf("got %v from flush after a failure, want the injected error", err)
+	}
+}
+
+func TestPlotErrorRaisesPen(t *testing.T) {
+	d := newDrawing([]Path{square(1, 1, 1)})
+
+	// a move fails with the pen down, which stops the device goroutine
+	cmdr := &recordingCommander{Simulator: NewSimulator(), failOnDown: true}
+	if err := PlotDrawing(cmdr, d, PlotOptions{}); !errors.Is(err, errInjected) {
+		t.Fatalf("got %v, want the injected error", err)
+	}
+	if !cmdr.penUp || cmdr.sent[len(cmdr.sent)-1] != "up" {
+		t.Errorf("the pen was left down after the error, having sent %v", cmdr.sent)
+	}
+
+	// waiting for the device to finish fails
+	cmdr = &recordingCommander{Simulator: NewSimulator(), query: errInjected}
+	if err := PlotDrawing(cmdr, d, PlotOptions{}); !errors.Is(err, errInjected) {
+		t.Fatalf("got %v, want the injected error", err)
+	}
+	if !cmdr.penUp {
+		t.Errorf("the pen was left down after the error, having sent %v", cmdr.sent)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"time"
 )
 
 type Vec2d struct {
@@ -16,7 +15,7 @@ func (p Vec2d) String() string {
 }
 
 func (p Vec2d) Dot(other Vec2d) float64 {
-	return p.x*other.x + p.y*p.y
+	return p.x*other.x + p.y*other.y
 }
 
 func (p Vec2d) Distance(other Vec2d) float64 {
@@ -78,8 +77,57 @@ func (p Vec2d) Subtract(other Vec2d) Vec2d {
 	}
 }
 
-func makePlan(points []Vec2d, accel, cornerFactor float64, debug bool) Plan {
-	vmax := float64(4)
+// MotionProfile holds the limits that the planner keeps a path's motion within.
+type MotionProfile struct {
+	// Accel is the acceleration, in inches per second squared.
+	Accel float64
+	// MaxVelocity is the top speed, in inches per second.
+	MaxVelocity float64
+	// CornerFactor controls how fast corners are taken; larger values round them off faster.
+	CornerFactor float64
+	// Jerk, if non-zero, limits how quickly the acceleration can change, in
+	// inches per second cubed, producing S-curve profiles instead of trapezoids.
+	Jerk float64
+}
+
+// MotionProfiles holds separate profiles for pen-up travel and pen-down drawing.
+type MotionProfiles struct {
+	PenUp   MotionProfile
+	PenDown MotionProfile
+}
+
+// DefaultMotionProfiles draws at the 4 inches per second that every path
+// used to be planned at, and travels with the pen up at twice that. Nothing
+// is drawn while the pen is up, so travel doesn't need to be held to the
+// speed at which ink flows evenly, only to what the motors can manage.
+var DefaultMotionProfiles = MotionProfiles{
+	PenUp:   MotionProfile{Accel: 16, MaxVelocity: 8, CornerFactor: 0.001},
+	PenDown: MotionProfile{Accel: 16, MaxVelocity: 4, CornerFactor: 0.001},
+}
+
+// forPath returns the profile that applies to the path.
+func (m MotionProfiles) forPath(path PenPath) MotionProfile {
+	if path.penUp {
+		return m.PenUp
+	}
+	return m.PenDown
+}
+
+func makePlan(points []Vec2d, profile MotionProfile, debug bool) Plan {
+	accel, vmax, cornerFactor := profile.Accel, profile.MaxVelocity, profile.CornerFactor
+
+	// drop repeated points, they make zero-length segments that have no direction
+	deduped := make([]Vec2d, 0, len(points))
+	for i, point := range points {
+		if i == 0 || point != points[i-1] {
+			deduped = append(deduped, point)
+		}
+	}
+	points = deduped
+	if len(points) < 2 {
+		return Plan{}
+	}
+
 	thr := throttler{.02, points, .001, vmax, nil}
 	thr.init()
 
@@ -90,13 +138,14 @@ func makePlan(points []Vec2d, accel, cornerFactor float64, debug bool) Plan {
 		segments = append(segments, Segment{p1: points[i-1], p2: points[i]})
 	}
 
-	// Compute a max entry velocity for each segment
+	// Compute a max entry velocity for each segment, limited by the angle of
+	// the corner it starts at and how fast the throttler allows that point to be passed
 	for i := 0; i < len(segments)-1; i++ {
-		in, out := segments[i], segments[i+1]
-		if in.maxEntryVelocity > maxVelocities[i] {
-			in.maxEntryVelocity = maxVelocities[i]
-		}
-		out.maxEntryVelocity = cornerVelocity(in, out, vmax, accel, cornerFactor)
+		in, out := &segments[i], &segments[i+1]
+		out.maxEntryVelocity = math.Min(
+			cornerVelocity(*in, *out, vmax, accel, cornerFactor),
+			maxVelocities[i+1],
+		)
 	}
 
 	// add a dummy segment at the end to force a final velocity of zero
@@ -108,6 +157,11 @@ func makePlan(points []Vec2d, accel, cornerFactor float64, debug bool) Plan {
 		},
 	)
 
+	if profile.Jerk > 0 {
+		planSCurves(segments, profile)
+		return newPlan(segments[:len(segments)-1])
+	}
+
 	var i int
 	for i < len(segments)-1 {
 		segment, nextSegment := &segments[i], &segments[i+1]
@@ -145,6 +199,7 @@ func makePlan(points []Vec2d, accel, cornerFactor float64, debug bool) Plan {
 					segment.entryVelocity,
 					segment.p1,
 					segment.p2,
+					0,
 				},
 			}
 			nextSegment.entryVelocity = vf
@@ -158,9 +213,9 @@ func makePlan(points []Vec2d, accel, cornerFactor float64, debug bool) Plan {
 			// accelerate, cruise, decelerate
 			z := newTrapezoid(segmentLength, segment.entryVelocity, vmax, vExit, accel, segment.p1, segment.p2)
 			segment.blocks = []Block{
-				{accel, z.t1, segment.entryVelocity, z.p1, z.p2},
-				{0, z.t2, vmax, z.p2, z.p3},
-				{-accel, z.t3, vmax, z.p3, z.p4},
+				{accel, z.t1, segment.entryVelocity, z.p1, z.p2, 0},
+				{0, z.t2, vmax, z.p2, z.p3, 0},
+				{-accel, z.t3, vmax, z.p3, z.p4, 0},
 			}
 			nextSegment.entryVelocity = vExit
 			i++
@@ -170,25 +225,14 @@ func makePlan(points []Vec2d, accel, cornerFactor float64, debug bool) Plan {
 			fmt.Println("here4")
 		}
 		segment.blocks = []Block{
-			{accel, profile.t1, segment.entryVelocity, profile.p1, profile.p2},
-			{-1 * accel, profile.t2, profile.vmax, profile.p2, profile.p3},
+			{accel, profile.t1, segment.entryVelocity, profile.p1, profile.p2, 0},
+			{-1 * accel, profile.t2, profile.vmax, profile.p2, profile.p3, 0},
 		}
 		nextSegment.entryVelocity = vExit
 		i++
 	}
-	var blocks []Block
-	var totalTime float64
-	for _, s := range segments {
-		blocks = append(blocks, s.blocks...)
-		for _, b := range s.blocks {
-			//fmt.Printf("%d, %d: %#v\n", i, j, b)
-			totalTime += b.t
-		}
-	}
-	return Plan{
-		blocks:    blocks,
-		totalTime: totalTime,
-	}
+	// leave off the dummy segment, it never has any blocks
+	return newPlan(segments[:len(segments)-1])
 }
 
 type throttler struct {
@@ -278,19 +322,79 @@ type Plan struct {
 	blocks      []Block
 	totalTime   float64
 	totalLength float64
+
+	// startTimes and startDistances hold the offset of each block from the
+	// beginning of the plan, for looking up instants by time.
+	startTimes     []float64
+	startDistances []float64
+
+	// segments describes the segments of the path that the blocks were planned for.
+	segments []PlanSegment
 }
 
-func newPlan(blocks []Block) Plan {
-	totalTime := time.Duration(0)
-	totalLength := float64(0)
-	//for _, b := range blocks {
-	//totalTime += b.t
+// PlanSegment records how the planner treated one segment of a path.
+type PlanSegment struct {
+	start, end Vec2d
+	// maxEntryVelocity is the fastest the segment could be entered, given the
+	// corner at its start, and entryVelocity is how fast it was actually entered.
+	maxEntryVelocity float64
+	entryVelocity    float64
+	// firstBlock and blockCount locate the segment's blocks within the plan.
+	firstBlock int
+	blockCount int
+}
 
-	//}
-	_ = totalLength
-	_ = totalTime
+func newPlan(segments []Segment) Plan {
+	plan := Plan{}
+	for _, s := range segments {
+		ps := PlanSegment{
+			start:            s.p1,
+			end:              s.p2,
+			maxEntryVelocity: s.maxEntryVelocity,
+			entryVelocity:    s.entryVelocity,
+			firstBlock:       len(plan.blocks),
+		}
+		for _, b := range s.blocks {
+			// drop zero-duration blocks, they don't move anything
+			if b.t <= EPS {
+				continue
+			}
+			plan.blocks = append(plan.blocks, b)
+			plan.startTimes = append(plan.startTimes, plan.totalTime)
+			plan.startDistances = append(plan.startDistances, plan.totalLength)
+			plan.totalTime += b.t
+			plan.totalLength += b.length()
+			ps.blockCount++
+		}
+		plan.segments = append(plan.segments, ps)
+	}
+	return plan
+}
 
-	return Plan{}
+// Instant describes the state of motion at a moment in time along a plan.
+type Instant struct {
+	t        float64
+	position Vec2d
+	distance float64
+	velocity float64
+	accel    float64
+}
+
+// instant returns the state of motion at time t (in seconds) from the start of the plan.
+// Times outside the plan are clamped to its start or end.
+func (p Plan) instant(t float64) Instant {
+	if len(p.blocks) == 0 {
+		return Instant{}
+	}
+	t = math.Max(0, math.Min(p.totalTime, t))
+	i := sort.Search(len(p.startTimes), func(i int) bool { return p.startTimes[i] > t }) - 1
+	if i < 0 {
+		i = 0
+	}
+	in := p.blocks[i].instant(t - p.startTimes[i])
+	in.t += p.startTimes[i]
+	in.distance += p.startDistances[i]
+	return in
 }
 
 type Block struct {
@@ -298,6 +402,33 @@ type Block struct {
 	t          float64
 	velocity   float64
 	start, end Vec2d
+
+	// jerk is the rate at which accel changes over the block. It's zero
+	// except for the blocks that make up S-curve profiles.
+	jerk float64
+}
+
+func (b Block) length() float64 {
+	return b.start.Distance(b.end)
+}
+
+// instant returns the state of motion at time t (in seconds) from the start of the block.
+func (b Block) instant(t float64) Instant {
+	t = math.Max(0, math.Min(b.t, t))
+	length := b.length()
+	s := b.velocity*t + b.accel*t*t/2 + b.jerk*t*t*t/6
+	s = math.Max(0, math.Min(length, s))
+	position := b.start
+	if length > 0 {
+		position = b.start.LinearInterpolate(b.end, s)
+	}
+	return Instant{
+		t:        t,
+		position: position,
+		distance: s,
+		velocity: b.velocity + b.accel*t + b.jerk*t*t/2,
+		accel:    b.accel + b.jerk*t,
+	}
 }
 
 type Segment struct {
@@ -315,7 +446,8 @@ const EPS = 1e-9
 
 func cornerVelocity(s1, s2 Segment, vmax, accel, cornerFactor float64) float64 {
 	cosine := -1 * s1.Vec().Dot(s2.Vec())
-	if cosine < EPS {
+	if math.Abs(cosine-1) < EPS {
+		// the path doubles back on itself
 		return 0
 	}
 	sine := math.Sqrt((1 - cosine) / 2)
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+var testProfile = MotionProfile{Accel: 16, MaxVelocity: 4, CornerFactor: 0.001}
+
+func TestVec2dDot(t *testing.T) {
+	if got := (Vec2d{1, 2}).Dot(Vec2d{3, 4}); got != 11 {
+		t.Errorf("Dot = %v, want 11", got)
+	}
+}
+
+func TestCornerVelocity(t *testing.T) {
+	const vmax, accel, cornerFactor = 4, 16, 0.001
+	in := Segment{p1: Vec2d{0, 0}, p2: Vec2d{1, 0}}
+	tests := []struct {
+		name     string
+		out      Vec2d
+		min, max float64
+	}{
+		{"straight on", Vec2d{2, 0}, vmax, vmax},
+		{"right angle", Vec2d{1, 1}, EPS, vmax - EPS},
+		{"doubling back", Vec2d{0, 0}, 0, 0},
+	}
+	for _, test := range tests {
+		out := Segment{p1: in.p2, p2: test.out}
+		v := cornerVelocity(in, out, vmax, accel, cornerFactor)
+		if v < test.min || v > test.max {
+			t.Errorf("%s: cornerVelocity = %v, want between %v and %v", test.name, v, test.min, test.max)
+		}
+	}
+}
+
+func TestMakePlanDoesNotStopAtStraightJoins(t *testing.T) {
+	whole := makePlan([]Vec2d{{0, 0}, {2, 0}}, testProfile, false)
+	split := makePlan([]Vec2d{{0, 0}, {1, 0}, {2, 0}}, testProfile, false)
+	if math.Abs(whole.totalTime-split.totalTime) > 1e-6 {
+		t.Errorf("plan with a point midway takes %vs, want %vs as without it", split.totalTime, whole.totalTime)
+	}
+}
+
+func TestMakePlanSkipsDuplicatePoints(t *testing.T) {
+	want := makePlan([]Vec2d{{0, 0}, {1, 0}, {1, 1}}, testProfile, false)
+	got := makePlan([]Vec2d{{0, 0}, {0, 0}, {1, 0}, {1, 0}, {1, 1}}, testProfile, false)
+	if math.IsNaN(got.totalTime) || math.Abs(got.totalTime-want.totalTime) > 1e-9 {
+		t.Errorf("plan with repeated points takes %vs, want %vs", got.totalTime, want.totalTime)
+	}
+	if plan := makePlan([]Vec2d{{1, 1}, {1, 1}}, testProfile, false); len(plan.blocks) != 0 {
+		t.Errorf("plan of a single repeated point has %d blocks, want none", len(plan.blocks))
+	}
+}
+
+func TestMakePlanIsValid(t *testing.T) {
+	profiles := []struct {
+		name    string
+		profile MotionProfile
+	}{
+		{"trapezoid", testProfile},
+		{"s-curve", MotionProfile{Accel: 16, MaxVelocity: 4, CornerFactor: 0.001, Jerk: 200}},
+	}
+	paths := []struct {
+		name string
+		path Path
+	}{
+		{"long straight line", Path{{0, 0}, {5, 3}}},
+		{"short straight line", Path{{0, 0}, {0.05, 0}}},
+		{"straight line through points", Path{{0, 0}, {1, 1}, {2, 2}, {3, 3}}},
+		{"sharp corners", Path{{0, 0}, {2, 0}, {0, 0.2}, {2, 0.4}, {0, 0.6}}},
+		{"doubling back", Path{{0, 0}, {1, 0}, {0, 0}}},
+		{"duplicate points", Path{{0, 0}, {0, 0}, {1, 0}, {1, 0}, {1, 1}, {1, 1}}},
+		{"circle", Circle(Vec2d{2, 2}, 1).Flatten(DefaultCurveTolerance)},
+	}
+	for _, p := range profiles {
+		for _, path := range paths {
+			plan := makePlan(path.path, p.profile, false)
+			if report := ValidatePlan(plan, path.path, p.profile); !report.OK() {
+				t.Errorf("%s, %s: %s", p.name, path.name, report)
+			}
+		}
+	}
+}
+
+func TestMakePlanProfiles(t *testing.T) {
+	path := Path{{0, 0}, {5, 0}}
+	cruising := false
+	for _, b := range makePlan(path, testProfile, false).blocks {
+		if b.jerk != 0 {
+			t.Errorf("trapezoid profile has a block with jerk %v", b.jerk)
+		}
+		if b.accel == 0 && math.Abs(b.velocity-testProfile.MaxVelocity) < 1e-9 {
+			cruising = true
+		}
+	}
+	if !cruising {
+		t.Error("trapezoid profile never cruises at max velocity on a long line")
+	}
+
+	jerky := testProfile
+	jerky.Jerk = 200
+	limited := false
+	for _, b := range makePlan(path, jerky, false).blocks {
+		if math.Abs(b.jerk) > jerky.Jerk+1e-9 {
+			t.Errorf("s-curve profile has a block with jerk %v over the limit", b.jerk)
+		}
+		limited = limited || b.jerk != 0
+	}
+	if !limited {
+		t.Error("s-curve profile has no blocks with jerk")
+	}
+}
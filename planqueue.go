@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// planQueueWindow is how many paths may be planned ahead of the one being
+// plotted, which bounds the memory held by plans waiting to be plotted.
+const planQueueWindow = 64
+
+// errPlanQueueClosed is returned when a plan is wanted from a queue that's been closed.
+var errPlanQueueClosed = errors.New("plan queue closed")
+
+// planQueue plans the paths of a drawing on a pool of worker goroutines, so
+// that later paths are planned while earlier ones are being plotted. Plans
+// are taken in order with get, each as soon as it's ready, and planning
+// stays at most planQueueWindow paths ahead of them.
+type planQueue struct {
+	plans []Plan
+	// ready[i] is closed once plans[i] has been planned.
+	ready []chan struct{}
+	// window holds a slot for each path that's been handed to a worker but
+	// not yet taken with get.
+	window chan struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu        sync.Mutex
+	planned   int
+	totalTime float64
+}
+
+// newPlanQueue starts planning the paths on the given number of workers,
+// or one per CPU if workers isn't positive.
+func newPlanQueue(paths []PenPath, profiles MotionProfiles, workers int) *planQueue {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	q := &planQueue{
+		plans:  make([]Plan, len(paths)),
+		ready:  make([]chan struct{}, len(paths)),
+		window: make(chan struct{}, planQueueWindow),
+		stop:   make(chan struct{}),
+	}
+	for i := range q.ready {
+		q.ready[i] = make(chan struct{})
+	}
+
+	// paths are handed out in order, so the first plans are ready first
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case q.window <- struct{}{}:
+			case <-q.stop:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-q.stop:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers && w < len(paths); w++ {
+		go func() {
+			for i := range jobs {
+				q.plans[i] = profiles.plan(paths[i])
+				q.mu.Lock()
+				q.planned++
+				q.totalTime += q.plans[i].totalTime
+				q.mu.Unlock()
+				close(q.ready[i])
+			}
+		}()
+	}
+	return q
+}
+
+// get waits for the plan for the given path. Each plan can only be taken
+// once, since the queue lets go of it to make room for the next. It returns
+// false if the queue is closed before the plan is ready.
+func (q *planQueue) get(i int) (Plan, bool) {
+	select {
+	case <-q.ready[i]:
+	default:
+		select {
+		case <-q.ready[i]:
+		case <-q.stop:
+			return Plan{}, false
+		}
+	}
+	plan := q.plans[i]
+	q.plans[i] = Plan{}
+	<-q.window
+	return plan, true
+}
+
+// estimatedTime returns the total time of the plans that are ready so far,
+// scaled up to cover the paths that haven't been planned yet as if they
+// took as long on average. It's exact once every path has been planned.
+func (q *planQueue) estimatedTime() float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.planned == 0 {
+		return 0
+	}
+	return q.totalTime * float64(len(q.plans)) / float64(q.planned)
+}
+
+// close stops any planning that hasn't started yet.
+func (q *planQueue) close() {
+	q.stopOnce.Do(func() { close(q.stop) })
+}
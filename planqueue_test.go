@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// gridDrawing returns a drawing of n small squares, which has twice as many
+// paths once the pen-up moves between them are counted.
+func gridDrawing(n int) Drawing {
+	var paths []Path
+	for i := 0; i < n; i++ {
+		paths = append(paths, square(0.5+float64(i%20)*0.4, 0.5+float64(i/20)*0.4, 0.2))
+	}
+	return newDrawing(paths)
+}
+
+func TestPlanQueue(t *testing.T) {
+	d := gridDrawing(100)
+	for _, workers := range []int{0, 1, 4} {
+		q := newPlanQueue(d.paths, DefaultMotionProfiles, workers)
+		for i, path := range d.paths {
+			plan, ok := q.get(i)
+			if !ok {
+				t.Fatalf("%d workers: plan %d wasn't ready", workers, i)
+			}
+			if want := DefaultMotionProfiles.plan(path); !reflect.DeepEqual(plan, want) {
+				t.Errorf("%d workers: plan %d differs from planning the path on its own", workers, i)
+			}
+		}
+		q.close()
+	}
+}
+
+// waitForPlanned waits until the number of paths planned stops changing, and returns it.
+func waitForPlanned(q *planQueue) int {
+	planned := -1
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		q.mu.Lock()
+		n := q.planned
+		q.mu.Unlock()
+		if n == planned {
+			break
+		}
+		planned = n
+	}
+	return planned
+}
+
+func TestPlanQueueWindow(t *testing.T) {
+	d := gridDrawing(100)
+	q := newPlanQueue(d.paths, DefaultMotionProfiles, 4)
+	defer q.close()
+
+	if planned := waitForPlanned(q); planned != planQueueWindow {
+		t.Errorf("planned %d paths before any were taken, want %d", planned, planQueueWindow)
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := q.get(i); !ok {
+			t.Fatalf("plan %d wasn't ready", i)
+		}
+	}
+	if planned := waitForPlanned(q); planned != planQueueWindow+10 {
+		t.Errorf("planned %d paths after 10 were taken, want %d", planned, planQueueWindow+10)
+	}
+}
+
+func TestPlanQueueClose(t *testing.T) {
+	d := gridDrawing(100)
+	q := newPlanQueue(d.paths, DefaultMotionProfiles, 4)
+	waitForPlanned(q)
+	q.close()
+
+	// plans that were ready before the queue closed can still be taken
+	if _, ok := q.get(0); !ok {
+		t.Error("a plan that was ready couldn't be taken after the queue closed")
+	}
+	// but get doesn't wait for ones that will never be planned
+	result := make(chan bool)
+	go func() {
+		_, ok := q.get(len(d.paths) - 1)
+		result <- ok
+	}()
+	select {
+	case ok := <-result:
+		if ok {
+			t.Error("got a plan for a path outside the window after the queue closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("get blocked on a closed queue")
+	}
+	q.close()
+}
+
+func TestPlotBeyondPlanWindow(t *testing.T) {
+	// the drawing has several times as many paths as are planned ahead
+	d := gridDrawing(4 * planQueueWindow)
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	report := sim.Report()
+	if stats := d.Stats(); report.PenLifts != stats.PenLifts {
+		t.Errorf("the plot lifted the pen %d times, want %d", report.PenLifts, stats.PenLifts)
+	}
+	if len(report.Violations) > 0 {
+		t.Errorf("the plot broke the limits: %v", report.Violations)
+	}
+	last := d.paths[len(d.paths)-1].Path
+	end := last[len(last)-1].Multiply(stepsPerInch)
+	if x, y := float64(sim.x), float64(sim.y); (Vec2d{x, y}).Distance(end) > 2 {
+		t.Errorf("the plot ended at (%v, %v), want %v", x, y, end)
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	// timeslice is the duration of each XM move that a plan gets chopped into.
+	timeslice = 10 * time.Millisecond
+
+	// buttonPollInterval is how often the PRG button is checked while plotting.
+	buttonPollInterval = 250 * time.Millisecond
+
+	// checkpointInterval is how often the progress of a plot is saved.
+	checkpointInterval = time.Second
+)
+
+// PlotOptions controls how a drawing gets plotted.
+type PlotOptions struct {
+	// Control, if set, allows the plot to be paused, resumed or aborted.
+	Control *PlotControl
+
+	// Checkpoints, if set, records the progress of the plot so that it can
+	// be resumed with ResumeDrawing if it's interrupted.
+	Checkpoints *CheckpointStore
+
+	// OnStatus, if set, is called periodically with the status of the plot.
+	OnStatus func(PlotStatus)
+
+	// Profiles sets the motion limits for pen-up and pen-down paths.
+	// If unset, DefaultMotionProfiles is used.
+	Profiles MotionProfiles
+
+	// Simplify, if set, thins out the points of each path before it's planned.
+	Simplify Simplification
+
+	// PlanWorkers is how many paths are planned at once while plotting.
+	// If unset, there's one worker per CPU.
+	PlanWorkers int
+
+	// Validate checks each plan with ValidatePlan before it's run, stopping
+	// the plot rather than sending an invalid plan to the motors.
+	Validate bool
+}
+
+// PlotDrawing sends each path of the drawing to the commander, raising or
+// lowering the pen as needed and stepping through the path's motion plan.
+// An aborted plot parks the pen, returns home and yields ErrPlotAborted.
+func PlotDrawing(cmdr Commander, d Drawing, opts PlotOptions) error {
+	d = d.Simplify(opts.Simplify)
+	if opts.Checkpoints != nil {
+		if err := opts.Checkpoints.SaveDrawing(d); err != nil {
+			return err
+		}
+	}
+	return plotFrom(cmdr, d, Progress{}, opts)
+}
+
+// ResumeDrawing homes the machine and continues the plot stored in the
+// checkpoint store from the last progress that was recorded. Homing returns
+// the carriage to where the motors were enabled, which is only where the plot
+// started if they've stayed powered since. After a power loss, the carriage
+// has to be moved back to the plot's starting point by hand before resuming.
+func ResumeDrawing(cmdr Commander, opts PlotOptions) error {
+	if opts.Checkpoints == nil {
+		return errors.New("no checkpoint store to resume from")
+	}
+	d, progress, err := opts.Checkpoints.Load()
+	if err != nil {
+		return err
+	}
+	if err := cmdr.PenUp(); err != nil {
+		return err
+	}
+	if err := cmdr.Home(); err != nil {
+		return err
+	}
+	log.Printf("resuming plot at path %d of %d", progress.PathIndex+1, len(d.paths))
+	return plotFrom(cmdr, d, progress, opts)
+}
+
+func plotFrom(cmdr Commander, d Drawing, from Progress, opts PlotOptions) error {
+	p := newPlotter(opts)
+	version, err := cmdr.Version()
+	if err != nil {
+		return err
+	}
+	p.lowLevel = supportsLowLevelMoves(version)
+	if !p.lowLevel {
+		log.Printf("firmware %q doesn't support LM moves, falling back to timesliced moves", version)
+	}
+	if opts.Control != nil {
+		opts.Control.begin()
+		defer opts.Control.end()
+	}
+	p.queue = newCommandQueue(cmdr, opts.Control)
+	err = p.plot(d, from)
+	if err == ErrPlotAborted {
+		err = p.park()
+	} else if err == nil {
+		err = p.setPen(true)
+	}
+	if err == nil || err == ErrPlotAborted {
+		if flushErr := p.queue.flush(); flushErr != nil {
+			err = flushErr
+		}
+	}
+	// a plot that failed part way through mustn't leave the pen down on the
+	// paper, bleeding ink, so raise it through the queue if that's still
+	// running, or straight to the device once the queue has stopped
+	raised := true
+	if err != nil && err != ErrPlotAborted {
+		raised = p.setPen(true) == nil && p.queue.flush() == nil
+	}
+	// once the queue is closed, the progress and status are no longer being
+	// updated from the device goroutine
+	p.queue.close()
+	if !raised {
+		if penErr := cmdr.PenUp(); penErr != nil {
+			log.Printf("failed to raise the pen: %s", penErr)
+		}
+	}
+	if p.status != nil {
+		p.status.done()
+	}
+	if err != nil && err != ErrPlotAborted {
+		// keep the checkpoint, with the latest progress, so the plot can be resumed
+		p.saveProgress()
+		return err
+	}
+	if opts.Checkpoints != nil {
+		if clearErr := opts.Checkpoints.Clear(); clearErr != nil {
+			log.Printf("failed to clear checkpoint: %s", clearErr)
+		}
+	}
+	return err
+}
+
+type plotter struct {
+	// queue sends commands from the device goroutine. Everything sent to
+	// the device while plotting goes through it.
+	queue        *commandQueue
+	ctl          *PlotControl
+	checkpoints  *CheckpointStore
+	profiles     MotionProfiles
+	planWorkers  int
+	validate     bool
+	stepsPerUnit float64
+	// lowLevel is set when the firmware can run each block as an LM move,
+	// rather than being sent the plan in timeslices.
+	lowLevel bool
+
+	// penUp, x and y are where the carriage will be once the queued commands
+	// have run, with x and y in steps relative to where the plot started.
+	penUp bool
+	x, y  int
+	// errX and errY carry the fractional steps left over from previous moves,
+	// so that rounding each move to whole steps doesn't accumulate into drift.
+	errX, errY float64
+
+	// onStatus, status, progress and lastCheckpoint are updated from the
+	// device goroutine, as the commands for each move are sent.
+	onStatus       func(PlotStatus)
+	status         *statusTracker
+	progress       Progress
+	lastCheckpoint time.Time
+}
+
+func newPlotter(opts PlotOptions) *plotter {
+	profiles := opts.Profiles
+	if profiles == (MotionProfiles{}) {
+		profiles = DefaultMotionProfiles
+	}
+	return &plotter{
+		profiles:     profiles,
+		planWorkers:  opts.PlanWorkers,
+		validate:     opts.Validate,
+		ctl:          opts.Control,
+		checkpoints:  opts.Checkpoints,
+		onStatus:     opts.OnStatus,
+		stepsPerUnit: stepsPerInch,
+		penUp:        true,
+	}
+}
+
+// plot draws the paths of the drawing, starting from the given progress.
+// The paths are planned concurrently, and each one is plotted as soon as
+// its plan is ready.
+func (p *plotter) plot(d Drawing, from Progress) error {
+	plans := newPlanQueue(d.paths, p.profiles, p.planWorkers)
+	defer plans.close()
+	p.status = newStatusTracker(plans, p.onStatus)
+	for i := 0; i < from.PathIndex && i < len(d.paths); i++ {
+		plan, ok := plans.get(i)
+		if !ok {
+			return errPlanQueueClosed
+		}
+		p.status.skip(plan.totalTime, plan.totalLength, d.paths[i].penUp)
+	}
+
+	for i := from.PathIndex; i < len(d.paths); i++ {
+		i := i
+		plan, ok := plans.get(i)
+		if !ok {
+			return errPlanQueueClosed
+		}
+		path, offset := d.paths[i], float64(0)
+		if p.validate {
+			if report := ValidatePlan(plan, path.Path, p.profiles.forPath(path)); !report.OK() {
+				return fmt.Errorf("invalid plan for path %d: %s", i, report.Violations[0])
+			}
+		}
+		if i == from.PathIndex && from.Distance > 0 {
+			offset = from.Distance
+			path.Path = path.Path.after(offset)
+			full := plan
+			plan = p.profiles.plan(path)
+			p.status.skip(full.totalTime-plan.totalTime, offset, path.penUp)
+		}
+		if i == from.PathIndex && len(path.Path) > 0 && p.position().Distance(path.Path[0]) > EPS {
+			// travel to where the plot left off
+			travel := PenPath{Path{p.position(), path.Path[0]}, true}
+			if err := p.plotPath(travel, p.profiles.plan(travel), nil); err != nil {
+				return err
+			}
+		}
+		err := p.then(func() { p.progress = Progress{i, offset} })
+		if err != nil {
+			return err
+		}
+		err = p.plotPath(path, plan, func(in Instant) {
+			p.recordProgress(Progress{i, offset + in.distance})
+			p.status.update(i, path.penUp, in)
+		})
+		if err != nil {
+			return err
+		}
+		err = p.then(func() { p.status.finishPath(i, plan, path.penUp) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Plans computes the motion plan for each path of the drawing, planning
+// paths concurrently with one worker per CPU.
+func (d Drawing) Plans(profiles MotionProfiles) []Plan {
+	q := newPlanQueue(d.paths, profiles, 0)
+	defer q.close()
+	plans := make([]Plan, len(d.paths))
+	for i := range plans {
+		// the queue is only closed once every plan has been taken
+		plans[i], _ = q.get(i)
+	}
+	return plans
+}
+
+// plan computes the motion plan for the path using the profile for its pen state.
+func (m MotionProfiles) plan(path PenPath) Plan {
+	return makePlan(path.Path, m.forPath(path), false)
+}
+
+// plotPath runs the plan for the path, calling onMove (if non-nil) with the instant reached after each move.
+func (p *plotter) plotPath(path PenPath, plan Plan, onMove func(Instant)) error {
+	if err := p.setPen(path.penUp); err != nil {
+		return err
+	}
+	return p.runPlan(plan, onMove)
+}
+
+func (p *plotter) setPen(up bool) error {
+	err := p.send(func(cmdr Commander) error {
+		if up {
+			return cmdr.PenUp()
+		}
+		return cmdr.PenDown()
+	})
+	if err != nil {
+		return err
+	}
+	p.penUp = up
+	return nil
+}
+
+// send queues a command for the device.
+func (p *plotter) send(command func(Commander) error) error {
+	return p.queue.push(queuedCommand{send: command})
+}
+
+// then queues f to be called once the commands queued before it have been sent.
+func (p *plotter) then(f func()) error {
+	return p.queue.push(queuedCommand{then: f})
+}
+
+// moved queues a call to onMove, if it's non-nil, for once the carriage has reached the instant.
+func (p *plotter) moved(onMove func(Instant), in Instant) error {
+	if onMove == nil {
+		return nil
+	}
+	return p.then(func() { onMove(in) })
+}
+
+// runPlan samples the plan once per timeslice and moves the steppers by the
+// difference between consecutive samples.
+func (p *plotter) runPlan(plan Plan, onMove func(Instant)) error {
+	if p.lowLevel {
+		return p.runPlanLowLevel(plan, onMove)
+	}
+	step := timeslice.Seconds()
+	for t := float64(0); t < plan.totalTime; t += step {
+		i1, i2 := plan.instant(t), plan.instant(t+step)
+		if err := p.safePoint(i1.velocity); err != nil {
+			return err
+		}
+		d := i2.position.Subtract(i1.position)
+		var sx, sy float64
+		sx, p.errX = math.Modf(d.x*p.stepsPerUnit + p.errX)
+		sy, p.errY = math.Modf(d.y*p.stepsPerUnit + p.errY)
+		if err := p.move(int(sx), int(sy), timeslice); err != nil {
+			return err
+		}
+		if err := p.moved(onMove, i2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// position returns the current position of the carriage in drawing units.
+func (p *plotter) position() Vec2d {
+	return Vec2d{float64(p.x) / p.stepsPerUnit, float64(p.y) / p.stepsPerUnit}
+}
+
+// recordProgress notes how far the plot has got, periodically saving it to the checkpoint store.
+func (p *plotter) recordProgress(progress Progress) {
+	p.progress = progress
+	if time.Since(p.lastCheckpoint) < checkpointInterval {
+		return
+	}
+	p.saveProgress()
+}
+
+func (p *plotter) saveProgress() {
+	if p.checkpoints == nil {
+		return
+	}
+	p.lastCheckpoint = time.Now()
+	if err := p.checkpoints.SaveProgress(p.progress); err != nil {
+		log.Printf("failed to save checkpoint: %s", err)
+	}
+}
+
+func (p *plotter) move(stepsX, stepsY int, duration time.Duration) error {
+	err := p.send(func(cmdr Commander) error {
+		return cmdr.Move(stepsX, stepsY, duration)
+	})
+	if err != nil {
+		return err
+	}
+	p.x += stepsX
+	p.y += stepsY
+	return nil
+}
+
+// safePoint is called between moves, with the velocity that the carriage
+// will be moving at when the next one starts. If the plot has been paused
+// and the carriage is at a standstill, it waits for the queued moves to
+// finish, raises the pen and blocks until the plot is resumed or aborted.
+// Stopping mid-move would throw away the speed that the plan carries into
+// the following moves, so a pause while moving is left until the plan comes
+// to a stop, which it does at the end of every path. An aborted plot stops
+// straight away, but it also waits for the queued moves, so that the
+// carriage is where the plotter expects when it's parked.
+func (p *plotter) safePoint(velocity float64) error {
+	if p.ctl == nil {
+		return nil
+	}
+	paused, aborted, changed := p.ctl.state()
+	if !aborted && (!paused || velocity > EPS) {
+		return nil
+	}
+	if err := p.queue.flush(); err != nil {
+		return err
+	}
+	if aborted {
+		return ErrPlotAborted
+	}
+
+	log.Printf("plot paused")
+	wasUp := p.penUp
+	if err := p.setPen(true); err != nil {
+		return err
+	}
+	// the device goroutine keeps polling the PRG button while the plot is paused
+	for paused && !aborted {
+		<-changed
+		paused, aborted, changed = p.ctl.state()
+	}
+	if aborted {
+		return ErrPlotAborted
+	}
+	log.Printf("plot resumed")
+	return p.setPen(wasUp)
+}
+
+// park raises the pen and returns the carriage to where the plot started.
+func (p *plotter) park() error {
+	log.Printf("plot aborted, returning home")
+	// the plot is already aborted, so stop checking for pauses
+	p.ctl = nil
+	if err := p.setPen(true); err != nil {
+		return err
+	}
+	current := p.position()
+	p.errX, p.errY = 0, 0
+	if current.Magnitude() > 0 {
+		plan := makePlan([]Vec2d{current, {0, 0}}, p.profiles.PenUp, false)
+		if err := p.runPlan(plan, nil); err != nil {
+			return err
+		}
+	}
+	// make up for any steps lost to rounding on the way home
+	if p.x != 0 || p.y != 0 {
+		if err := p.move(-p.x, -p.y, timeslice); err != nil {
+			return err
+		}
+	}
+	return ErrPlotAborted
+}
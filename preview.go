@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+const (
+	// previewSampleTime is how finely the plans are sampled when colouring strokes, in seconds.
+	previewSampleTime = 0.005
+
+	// cornerSlowdown is the fraction of the max velocity below which a corner is highlighted.
+	cornerSlowdown = 0.25
+
+	previewMargin       = 10
+	previewLegendHeight = 50
+)
+
+// velocityStops is the colour scale used for velocities, from stopped to full speed.
+var velocityStops = []color.RGBA{
+	{0, 0, 255, 255},
+	{0, 200, 255, 255},
+	{0, 200, 0, 255},
+	{255, 200, 0, 255},
+	{255, 0, 0, 255},
+}
+
+// velocityColor returns the colour for a velocity, given as a fraction of the max velocity.
+func velocityColor(f float64) color.Color {
+	f = math.Max(0, math.Min(1, f)) * float64(len(velocityStops)-1)
+	i := int(f)
+	if i >= len(velocityStops)-1 {
+		return velocityStops[len(velocityStops)-1]
+	}
+	c0, c1 := velocityStops[i], velocityStops[i+1]
+	t := f - float64(i)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + t*(float64(b)-float64(a)))
+	}
+	return color.RGBA{mix(c0.R, c1.R), mix(c0.G, c1.G), mix(c0.B, c1.B), 255}
+}
+
+// RenderVelocity renders a diagnostic view of how the drawing will be plotted,
+// with scale pixels per drawing unit. Pen-down strokes are coloured by their
+// planned velocity, corners where the planner slows right down are circled,
+// and pen-up travel is dashed.
+func (d Drawing) RenderVelocity(profiles MotionProfiles, scale float64) (*bytes.Buffer, error) {
+	topLeft, bottomRight := Bounds(d.paths)
+	// keep the image wide enough for the legend, however narrow the drawing
+	width := int((bottomRight.x-topLeft.x)*scale + 2*previewMargin)
+	if legend := velocityLegendWidth(); width < legend {
+		width = legend
+	}
+	height := int((bottomRight.y-topLeft.y)*scale+2*previewMargin) + previewLegendHeight
+	dc := gg.NewContext(width, height)
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	toImage := func(p Vec2d) (float64, float64) {
+		return (p.x-topLeft.x)*scale + previewMargin, (p.y-topLeft.y)*scale + previewMargin
+	}
+	vmax := profiles.PenDown.MaxVelocity
+
+	dc.SetLineCap(gg.LineCapRound)
+	for i, plan := range d.Plans(profiles) {
+		path := d.paths[i]
+		if path.penUp {
+			dc.SetColor(color.Gray{160})
+			dc.SetLineWidth(1)
+			dc.SetDash(4, 4)
+			for _, point := range path.Path {
+				dc.LineTo(toImage(point))
+			}
+			dc.Stroke()
+			dc.SetDash()
+			continue
+		}
+
+		dc.SetLineWidth(2)
+		for t := float64(0); t < plan.totalTime; t += previewSampleTime {
+			i1 := plan.instant(t)
+			i2 := plan.instant(t + previewSampleTime)
+			dc.SetColor(velocityColor((i1.velocity + i2.velocity) / 2 / vmax))
+			dc.MoveTo(toImage(i1.position))
+			dc.LineTo(toImage(i2.position))
+			dc.Stroke()
+		}
+
+		dc.SetColor(color.RGBA{200, 0, 200, 255})
+		dc.SetLineWidth(1)
+		for j, segment := range plan.segments {
+			if j == 0 || segment.maxEntryVelocity >= cornerSlowdown*vmax {
+				continue
+			}
+			x, y := toImage(segment.start)
+			dc.DrawCircle(x, y, 4)
+			dc.Stroke()
+		}
+	}
+
+	drawVelocityLegend(dc, vmax)
+
+	out := &bytes.Buffer{}
+	if err := dc.EncodePNG(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const (
+	legendBarWidth, legendBarHeight = 150, 10
+	// legendGap is the space between the items of the legend
+	legendGap = 20
+	// legendCornerWidth and legendTravelWidth are the widths of the corner
+	// and travel markers, along with the space before their labels
+	legendCornerWidth = 14
+	legendTravelWidth = 38
+)
+
+// velocityLegendWidth returns how wide the legend drawn by drawVelocityLegend is, margins included.
+func velocityLegendWidth() int {
+	dc := gg.NewContext(1, 1)
+	corner, _ := dc.MeasureString("corner slowdown")
+	travel, _ := dc.MeasureString("pen-up travel")
+	return int(math.Ceil(2*previewMargin + legendBarWidth + 2*legendGap + legendCornerWidth + legendTravelWidth + corner + travel))
+}
+
+// drawVelocityLegend draws the colour scale and markers along the bottom of the image.
+func drawVelocityLegend(dc *gg.Context, vmax float64) {
+	x := float64(previewMargin)
+	y := float64(dc.Height() - previewLegendHeight + previewMargin)
+
+	for i := 0; i < legendBarWidth; i++ {
+		dc.SetColor(velocityColor(float64(i) / legendBarWidth))
+		dc.DrawRectangle(x+float64(i), y, 1, legendBarHeight)
+		dc.Fill()
+	}
+	dc.SetColor(color.Black)
+	dc.DrawStringAnchored("0", x, y+legendBarHeight+4, 0, 1)
+	dc.DrawStringAnchored(fmt.Sprintf("%.1f in/s", vmax), x+legendBarWidth, y+legendBarHeight+4, 1, 1)
+
+	x += legendBarWidth + legendGap
+	dc.SetColor(color.RGBA{200, 0, 200, 255})
+	dc.SetLineWidth(1)
+	dc.DrawCircle(x+4, y+legendBarHeight/2, 4)
+	dc.Stroke()
+	dc.SetColor(color.Black)
+	dc.DrawStringAnchored("corner slowdown", x+legendCornerWidth, y+legendBarHeight/2, 0, 0.5)
+
+	width, _ := dc.MeasureString("corner slowdown")
+	x += legendCornerWidth + width + legendGap
+	dc.SetColor(color.Gray{160})
+	dc.SetDash(4, 4)
+	dc.DrawLine(x, y+legendBarHeight/2, x+30, y+legendBarHeight/2)
+	dc.Stroke()
+	dc.SetDash()
+	dc.SetColor(color.Black)
+	dc.DrawStringAnchored("pen-up travel", x+legendTravelWidth, y+legendBarHeight/2, 0, 0.5)
+}
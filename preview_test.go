@@ -0,0 +1,71 @@
+package main
+
+import (
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestVelocityColor(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want color.Color
+	}{
+		{-1, velocityStops[0]},
+		{0, velocityStops[0]},
+		{0.5, velocityStops[2]},
+		{1, velocityStops[len(velocityStops)-1]},
+		{2, velocityStops[len(velocityStops)-1]},
+	}
+	for _, test := range tests {
+		if got := velocityColor(test.f); got != test.want {
+			t.Errorf("velocityColor(%v) = %v, want %v", test.f, got, test.want)
+		}
+	}
+}
+
+func TestRenderVelocity(t *testing.T) {
+	// a line long enough to reach full speed, and a tiny one
+	d := newDrawing([]Path{{{1, 1}, {5, 1}}, {{6, 1}, {6.01, 1}}})
+	profiles := DefaultMotionProfiles
+	encoded, err := d.RenderVelocity(profiles, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := img.Bounds()
+	// the drawing reaches six inches from the origin, and the image has room for it and the legend
+	if bounds.Dx() < velocityLegendWidth() || bounds.Dx() < 600+2*previewMargin {
+		t.Errorf("the image is %d pixels wide, too narrow for the drawing or the legend", bounds.Dx())
+	}
+	if bounds.Dy() <= previewLegendHeight {
+		t.Errorf("the image is %d pixels high, with no room above the legend", bounds.Dy())
+	}
+
+	// the middle of the long line is drawn at full speed, and its start
+	// slower; the image starts at the origin, where the travel to it does
+	r, g, b, _ := img.At(previewMargin+300, previewMargin+100).RGBA()
+	if r>>8 < 200 || g>>8 > 100 || b>>8 > 100 {
+		t.Errorf("the middle of the line is (%d, %d, %d), want the full speed colour", r>>8, g>>8, b>>8)
+	}
+	r, _, b, _ = img.At(previewMargin+101, previewMargin+100).RGBA()
+	if r>>8 > 100 || b>>8 < 150 {
+		t.Errorf("the start of the line is (%d, _, %d), want a slow colour", r>>8, b>>8)
+	}
+
+	// a drawing narrower than the legend still fits it
+	small := newDrawing([]Path{{{0, 0}, {0.1, 0}}})
+	encoded, err = small.RenderVelocity(profiles, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img, err = png.Decode(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if width := img.Bounds().Dx(); width < velocityLegendWidth() {
+		t.Errorf("the image is %d pixels wide, narrower than the %d pixel legend", width, velocityLegendWidth())
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// PlotStatus is a snapshot of how far along a plot is.
+type PlotStatus struct {
+	PathIndex int
+	PathCount int
+
+	// Percent is the share of the planned motion time that has been completed.
+	// Paths are planned while the plot runs, so until they all have been, the
+	// total time that Percent and Remaining are based on is estimated by
+	// assuming the paths still to be planned take as long on average as
+	// those planned so far.
+	Percent   float64
+	Elapsed   time.Duration
+	Remaining time.Duration
+
+	// DownDistance and UpDistance are the distances covered with the pen down and up, in drawing units.
+	DownDistance float64
+	UpDistance   float64
+
+	// Done is set on the final update, once the plot has stopped.
+	Done bool
+}
+
+// statusTracker turns the instants reached while running each path's plan
+// into PlotStatus updates for the whole drawing.
+type statusTracker struct {
+	onStatus func(PlotStatus)
+	start    time.Time
+	plans    *planQueue
+
+	// pathTime, upDistance and downDistance cover the paths that are finished.
+	pathTime     float64
+	upDistance   float64
+	downDistance float64
+
+	status     PlotStatus
+	lastUpdate time.Time
+}
+
+// statusInterval is how often status updates are sent while plotting.
+const statusInterval = 200 * time.Millisecond
+
+func newStatusTracker(plans *planQueue, onStatus func(PlotStatus)) *statusTracker {
+	t := &statusTracker{
+		onStatus: onStatus,
+		start:    time.Now(),
+		plans:    plans,
+	}
+	t.status.PathCount = len(plans.plans)
+	return t
+}
+
+// skip counts the given motion time and distance as completed.
+func (t *statusTracker) skip(motionTime, distance float64, penUp bool) {
+	t.pathTime += motionTime
+	if penUp {
+		t.upDistance += distance
+	} else {
+		t.downDistance += distance
+	}
+}
+
+// update records the instant reached along the plan of the given path.
+func (t *statusTracker) update(pathIndex int, penUp bool, in Instant) {
+	t.status.PathIndex = pathIndex
+	t.status.UpDistance, t.status.DownDistance = t.upDistance, t.downDistance
+	if penUp {
+		t.status.UpDistance += in.distance
+	} else {
+		t.status.DownDistance += in.distance
+	}
+	completed := t.pathTime + in.t
+	totalTime := t.plans.estimatedTime()
+	if totalTime > 0 {
+		t.status.Percent = 100 * completed / totalTime
+	}
+	t.status.Remaining = seconds(totalTime - completed)
+	if time.Since(t.lastUpdate) >= statusInterval {
+		t.send()
+	}
+}
+
+// finishPath records the end of the plan for the given path.
+func (t *statusTracker) finishPath(pathIndex int, plan Plan, penUp bool) {
+	t.update(pathIndex, penUp, plan.instant(plan.totalTime))
+	t.skip(plan.totalTime, plan.totalLength, penUp)
+}
+
+// done sends the final status update.
+func (t *statusTracker) done() {
+	t.status.Done = true
+	t.send()
+}
+
+func (t *statusTracker) send() {
+	if t.onStatus == nil {
+		return
+	}
+	t.lastUpdate = time.Now()
+	t.status.Elapsed = time.Since(t.start)
+	t.onStatus(t.status)
+}
+
+func seconds(s float64) time.Duration {
+	if s < 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+// progressBarWidth is the number of characters in the bar drawn by NewProgressDisplay.
+const progressBarWidth = 30
+
+// NewProgressDisplay returns a status callback that draws a single, continuously
+// updated progress line on a terminal.
+func NewProgressDisplay(out io.Writer) func(PlotStatus) {
+	return func(s PlotStatus) {
+		filled := int(math.Round(s.Percent / 100 * progressBarWidth))
+		if filled > progressBarWidth {
+			filled = progressBarWidth
+		}
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+		fmt.Fprintf(
+			out,
+			"\r\033[K[%s] %5.1f%%  path %d/%d  elapsed %s  remaining %s  down %.2fin  up %.2fin",
+			bar,
+			s.Percent,
+			s.PathIndex+1,
+			s.PathCount,
+			s.Elapsed.Round(time.Second),
+			s.Remaining.Round(time.Second),
+			s.DownDistance,
+			s.UpDistance,
+		)
+		if s.Done {
+			fmt.Fprintln(out)
+		}
+	}
+}
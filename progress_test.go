@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlotStatus(t *testing.T) {
+	d := controlDrawing()
+	var statuses []PlotStatus
+	opts := PlotOptions{OnStatus: func(s PlotStatus) { statuses = append(statuses, s) }}
+	if err := PlotDrawing(NewSimulator(), d, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) < 2 {
+		t.Fatalf("got %d status updates, want at least the first and the last", len(statuses))
+	}
+	for i, s := range statuses {
+		if s.PathCount != len(d.paths) {
+			t.Errorf("update %d has %d paths, want %d", i, s.PathCount, len(d.paths))
+		}
+		if s.Done != (i == len(statuses)-1) {
+			t.Errorf("update %d has Done %v", i, s.Done)
+		}
+	}
+
+	last := statuses[len(statuses)-1]
+	stats := d.Stats()
+	if math.Abs(last.Percent-100) > 1e-6 || last.Remaining != 0 || last.PathIndex != len(d.paths)-1 {
+		t.Errorf("the last update is at %.2f%% of path %d with %s remaining, want 100%% of the last path", last.Percent, last.PathIndex, last.Remaining)
+	}
+	if math.Abs(last.DownDistance-stats.DownLength) > 1e-6 || math.Abs(last.UpDistance-stats.UpLength) > 1e-6 {
+		t.Errorf("the plot covered %.3fin down and %.3fin up, want %.3fin and %.3fin", last.DownDistance, last.UpDistance, stats.DownLength, stats.UpLength)
+	}
+}
+
+func TestPlanQueueEstimatedTime(t *testing.T) {
+	d := controlDrawing()
+	q := newPlanQueue(d.paths, DefaultMotionProfiles, 2)
+	defer q.close()
+	var total float64
+	for i := range d.paths {
+		plan, ok := q.get(i)
+		if !ok {
+			t.Fatalf("plan %d wasn't ready", i)
+		}
+		total += plan.totalTime
+	}
+	// once every path is planned, the estimate is exact
+	if estimate := q.estimatedTime(); math.Abs(estimate-total) > 1e-9 {
+		t.Errorf("estimated %v, want %v", estimate, total)
+	}
+	if stats := d.Stats(); math.Abs(stats.TotalTime.Seconds()-total) > 1e-6 {
+		t.Errorf("the plans take %vs, but the stats say %s", total, stats.TotalTime)
+	}
+}
+
+func TestProgressDisplay(t *testing.T) {
+	var out bytes.Buffer
+	display := NewProgressDisplay(&out)
+	display(PlotStatus{PathIndex: 1, PathCount: 4, Percent: 50, Remaining: 90 * time.Second})
+	if line := out.String(); !strings.Contains(line, " 50.0%") || !strings.Contains(line, "path 2/4") || strings.HasSuffix(line, "\n") {
+		t.Errorf("got %q, want a line at 50%% on path 2 of 4 that's redrawn", line)
+	}
+	out.Reset()
+	display(PlotStatus{PathIndex: 3, PathCount: 4, Percent: 100, Done: true})
+	if line := out.String(); !strings.Contains(line, "["+strings.Repeat("=", progressBarWidth)+"]") || !strings.HasSuffix(line, "\n") {
+		t.Errorf("got %q, want a full bar ending the line", line)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import "math"
+
+// sCurve is a jerk-limited change of velocity. The acceleration ramps up at
+// the jerk limit, holds at its peak and then ramps back down to zero, so
+// there's never an instantaneous change in acceleration.
+type sCurve struct {
+	v0, v1    float64
+	peakAccel float64
+	jerk      float64
+	rampTime  float64
+	holdTime  float64
+}
+
+func newSCurve(v0, v1, accel, jerk float64) sCurve {
+	dv := math.Abs(v1 - v0)
+	sign := float64(1)
+	if v1 < v0 {
+		sign = -1
+	}
+	c := sCurve{v0: v0, v1: v1, jerk: sign * jerk}
+	if dv >= accel*accel/jerk {
+		// there's enough of a change to reach full acceleration
+		c.rampTime = accel / jerk
+		c.holdTime = dv/accel - accel/jerk
+		c.peakAccel = sign * accel
+	} else {
+		c.rampTime = math.Sqrt(dv / jerk)
+		c.peakAccel = sign * jerk * c.rampTime
+	}
+	return c
+}
+
+func (c sCurve) duration() float64 {
+	return 2*c.rampTime + c.holdTime
+}
+
+// distance returns how far the curve travels. Since it's symmetric, the
+// average velocity is halfway between the start and end velocities.
+func (c sCurve) distance() float64 {
+	return (c.v0 + c.v1) / 2 * c.duration()
+}
+
+// blocks returns the constant-jerk blocks that make up the curve, positioned
+// along the segment starting at the given distance from its start.
+func (c sCurve) blocks(segment Segment, offset float64) []Block {
+	phases := []struct{ accel, jerk, t float64 }{
+		{0, c.jerk, c.rampTime},
+		{c.peakAccel, 0, c.holdTime},
+		{c.peakAccel, -c.jerk, c.rampTime},
+	}
+	direction := segment.Vec()
+	out := make([]Block, 0, len(phases))
+	v, s := c.v0, offset
+	start := segment.p1.Add(direction.Multiply(s))
+	for _, phase := range phases {
+		t := phase.t
+		s += v*t + phase.accel*t*t/2 + phase.jerk*t*t*t/6
+		end := segment.p1.Add(direction.Multiply(s))
+		out = append(out, Block{phase.accel, t, v, start, end, phase.jerk})
+		v += phase.accel*t + phase.jerk*t*t/2
+		start = end
+	}
+	return out
+}
+
+// planSCurves plans the segments with jerk-limited S-curves instead of
+// constant acceleration. It follows the same approach as makePlan, working
+// forwards through the segments and backtracking whenever a segment is
+// entered too fast to slow down for the next one in time.
+func planSCurves(segments []Segment, profile MotionProfile) {
+	accel, jerk, vmax := profile.Accel, profile.Jerk, profile.MaxVelocity
+	curve := func(v0, v1 float64) sCurve {
+		return newSCurve(v0, v1, accel, jerk)
+	}
+	distance := func(v0, v1 float64) float64 {
+		return curve(v0, v1).distance()
+	}
+
+	var i int
+	for i < len(segments)-1 {
+		segment, nextSegment := &segments[i], &segments[i+1]
+		segmentLength := segment.p1.Distance(segment.p2)
+		vi, vExit := segment.entryVelocity, nextSegment.maxEntryVelocity
+
+		if vi > vExit && distance(vi, vExit) > segmentLength+EPS {
+			// too fast, update max entry vel and backtrack
+			segment.maxEntryVelocity = solveVelocity(vExit, vi, segmentLength, func(v float64) float64 {
+				return distance(v, vExit)
+			})
+			i -= 1
+			continue
+		}
+
+		if vi < vExit && distance(vi, vExit) >= segmentLength {
+			// accelerate the whole way
+			vf := solveVelocity(vi, vExit, segmentLength, func(v float64) float64 {
+				return distance(vi, v)
+			})
+			segment.blocks = curve(vi, vf).blocks(*segment, 0)
+			nextSegment.entryVelocity = vf
+		} else {
+			peakDistance := func(v float64) float64 {
+				return distance(vi, v) + distance(v, vExit)
+			}
+			if peakDistance(vmax) <= segmentLength {
+				// accelerate, cruise, decelerate
+				up, down := curve(vi, vmax), curve(vmax, vExit)
+				cruise := segmentLength - up.distance() - down.distance()
+				segment.blocks = up.blocks(*segment, 0)
+				p2 := segment.blocks[len(segment.blocks)-1].end
+				p3 := p2.Add(segment.Vec().Multiply(cruise))
+				segment.blocks = append(segment.blocks, Block{0, cruise / vmax, vmax, p2, p3, 0})
+				segment.blocks = append(segment.blocks, down.blocks(*segment, up.distance()+cruise)...)
+			} else {
+				// accelerate, decelerate
+				peak := solveVelocity(math.Max(vi, vExit), vmax, segmentLength, peakDistance)
+				up, down := curve(vi, peak), curve(peak, vExit)
+				segment.blocks = append(up.blocks(*segment, 0), down.blocks(*segment, up.distance())...)
+			}
+			nextSegment.entryVelocity = vExit
+		}
+		// don't let rounding leave a gap before the next segment
+		segment.blocks[len(segment.blocks)-1].end = segment.p2
+		i++
+	}
+}
+
+// solveVelocity finds the velocity between low and high at which f reaches
+// the target, where f increases with velocity.
+func solveVelocity(low, high, target float64, f func(float64) float64) float64 {
+	for i := 0; i < 32; i++ {
+		v := (low + high) / 2
+		if f(v) > target {
+			high = v
+		} else {
+			low = v
+		}
+	}
+	return low
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+type SimplifyMethod int
+
+const (
+	SimplifyNone SimplifyMethod = iota
+	SimplifyDouglasPeucker
+	SimplifyVisvalingam
+)
+
+// ParseSimplifyMethod returns the method with the given name: "none", "dp" or "vw".
+func ParseSimplifyMethod(name string) (SimplifyMethod, error) {
+	switch name {
+	case "", "none":
+		return SimplifyNone, nil
+	case "dp", "douglas-peucker":
+		return SimplifyDouglasPeucker, nil
+	case "vw", "visvalingam":
+		return SimplifyVisvalingam, nil
+	}
+	return SimplifyNone, fmt.Errorf("unknown simplification method: %s", name)
+}
+
+// Simplification describes how to thin out the points of paths before they're planned.
+type Simplification struct {
+	Method SimplifyMethod
+	// Tolerance is how far, in drawing units, the simplified path may stray from the original.
+	Tolerance float64
+}
+
+func (s Simplification) apply(path Path) Path {
+	if s.Tolerance <= 0 {
+		return path
+	}
+	switch s.Method {
+	case SimplifyDouglasPeucker:
+		return path.SimplifyDouglasPeucker(s.Tolerance)
+	case SimplifyVisvalingam:
+		return path.SimplifyVisvalingam(s.Tolerance)
+	}
+	return path
+}
+
+// Simplify returns a copy of the drawing with its pen-down paths simplified.
+func (d Drawing) Simplify(s Simplification) Drawing {
+	if s.Method == SimplifyNone {
+		return d
+	}
+	out := Drawing{paths: make([]PenPath, 0, len(d.paths))}
+	for _, path := range d.paths {
+		if !path.penUp {
+			path.Path = s.apply(path.Path)
+		}
+		out.paths = append(out.paths, path)
+	}
+	return out
+}
+
+// SimplifyDouglasPeucker returns the path with the Douglas-Peucker algorithm
+// applied: every point that's removed lies within tolerance of the simplified path.
+func (p Path) SimplifyDouglasPeucker(tolerance float64) Path {
+	if len(p) < 3 {
+		return p
+	}
+	keep := make([]bool, len(p))
+	keep[0], keep[len(p)-1] = true, true
+
+	type span struct{ first, last int }
+	stack := []span{{0, len(p) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// find the point furthest from the line between the ends of the span
+		furthest, maxDistance := -1, float64(0)
+		for i := s.first + 1; i < s.last; i++ {
+			if d := p[i].SegmentDistance(p[s.first], p[s.last]); d > maxDistance {
+				furthest, maxDistance = i, d
+			}
+		}
+		if furthest < 0 || maxDistance <= tolerance {
+			continue
+		}
+		keep[furthest] = true
+		stack = append(stack, span{s.first, furthest}, span{furthest, s.last})
+	}
+
+	out := make(Path, 0, len(p))
+	for i, point := range p {
+		if keep[i] {
+			out = append(out, point)
+		}
+	}
+	return out
+}
+
+// SimplifyVisvalingam returns the path with the Visvalingam-Whyatt algorithm
+// applied: points are removed, least significant first, for as long as there's
+// one within tolerance of the line between its neighbours. Significance is
+// measured by the height of the triangle a point forms with its neighbours
+// rather than its area, so that the tolerance is a physical distance.
+func (p Path) SimplifyVisvalingam(tolerance float64) Path {
+	if len(p) < 3 {
+		return p
+	}
+
+	// the points form a linked list, so that neighbours can be found as points are removed
+	prev := make([]int, len(p))
+	next := make([]int, len(p))
+	removed := make([]bool, len(p))
+	h := &significanceHeap{index: make([]int, len(p))}
+	for i := range p {
+		prev[i], next[i] = i-1, i+1
+		h.index[i] = -1
+	}
+	significance := func(i int) float64 {
+		return p[i].SegmentDistance(p[prev[i]], p[next[i]])
+	}
+	for i := 1; i < len(p)-1; i++ {
+		heap.Push(h, pointSignificance{i, significance(i)})
+	}
+
+	for h.Len() > 0 {
+		least := heap.Pop(h).(pointSignificance)
+		if least.significance > tolerance {
+			break
+		}
+		i := least.point
+		removed[i] = true
+		next[prev[i]], prev[next[i]] = next[i], prev[i]
+		// a neighbour never becomes less significant than a point removed before it,
+		// so that points are removed in order of their effective significance
+		for _, n := range []int{prev[i], next[i]} {
+			if n == 0 || n == len(p)-1 {
+				continue
+			}
+			h.update(n, math.Max(significance(n), least.significance))
+		}
+	}
+
+	out := make(Path, 0, len(p))
+	for i, point := range p {
+		if !removed[i] {
+			out = append(out, point)
+		}
+	}
+	return out
+}
+
+type pointSignificance struct {
+	point        int
+	significance float64
+}
+
+// significanceHeap is a min-heap of points ordered by significance, which tracks
+// where each point is in the heap so that its significance can be updated.
+type significanceHeap struct {
+	items []pointSignificance
+	index []int
+}
+
+func (h significanceHeap) Len() int { return len(h.items) }
+func (h significanceHeap) Less(i, j int) bool {
+	return h.items[i].significance < h.items[j].significance
+}
+func (h significanceHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].point] = i
+	h.index[h.items[j].point] = j
+}
+
+func (h *significanceHeap) Push(x interface{}) {
+	item := x.(pointSignificance)
+	h.index[item.point] = len(h.items)
+	h.items = append(h.items, item)
+}
+
+func (h *significanceHeap) Pop() interface{} {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	h.index[item.point] = -1
+	return item
+}
+
+func (h *significanceHeap) update(point int, significance float64) {
+	i := h.index[point]
+	if i < 0 {
+		return
+	}
+	h.items[i].significance = significance
+	heap.Fix(h, i)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseSimplifyMethod(t *testing.T) {
+	tests := map[string]SimplifyMethod{
+		"":                SimplifyNone,
+		"none":            SimplifyNone,
+		"dp":              SimplifyDouglasPeucker,
+		"douglas-peucker": SimplifyDouglasPeucker,
+		"vw":              SimplifyVisvalingam,
+		"visvalingam":     SimplifyVisvalingam,
+	}
+	for name, want := range tests {
+		if got, err := ParseSimplifyMethod(name); err != nil || got != want {
+			t.Errorf("ParseSimplifyMethod(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseSimplifyMethod("fast"); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
+
+// wobblyLine is a line along x with points every 0.01in, each up to 0.002in off it.
+func wobblyLine() Path {
+	var path Path
+	for i := 0; i <= 100; i++ {
+		path = append(path, Vec2d{float64(i) / 100, 0.002 * math.Sin(float64(i))})
+	}
+	return path
+}
+
+// squareWithMidpoints is a unit square with a point in the middle of each side.
+var squareWithMidpoints = Path{{0, 0}, {0.5, 0}, {1, 0}, {1, 0.5}, {1, 1}, {0.5, 1}, {0, 1}, {0, 0.5}, {0, 0}}
+
+func TestSimplify(t *testing.T) {
+	methods := map[string]func(Path, float64) Path{
+		"dp": Path.SimplifyDouglasPeucker,
+		"vw": Path.SimplifyVisvalingam,
+	}
+	for name, simplify := range methods {
+		line := wobblyLine()
+		if got, want := simplify(line, 0.01), (Path{line[0], line[len(line)-1]}); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: the wobbly line simplified to %d points, want its ends", name, len(got))
+		}
+		if got := simplify(line, 0.0001); len(got) < len(line)/2 {
+			t.Errorf("%s: a tolerance smaller than the wobble left only %d points", name, len(got))
+		}
+		// the corners of a closed path are kept, so it stays closed
+		want := Path{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+		if got := simplify(squareWithMidpoints, 0.01); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: the square simplified to %v, want %v", name, got, want)
+		}
+		short := Path{{0, 0}, {1, 1}}
+		if got := simplify(short, 1); !reflect.DeepEqual(got, short) {
+			t.Errorf("%s: a single segment simplified to %v", name, got)
+		}
+	}
+}
+
+func TestSimplifyDouglasPeuckerTolerance(t *testing.T) {
+	var spiral Path
+	for i := 0; i <= 500; i++ {
+		a := float64(i) / 20
+		spiral = append(spiral, Vec2d{a * math.Cos(a) / 10, a * math.Sin(a) / 10})
+	}
+	const tolerance = 0.005
+	simplified := spiral.SimplifyDouglasPeucker(tolerance)
+	if len(simplified) >= len(spiral) {
+		t.Errorf("the spiral kept all %d points", len(spiral))
+	}
+	// every point that's removed is within the tolerance of the simplified path
+	for _, p := range spiral {
+		distance := math.Inf(1)
+		for i := 1; i < len(simplified); i++ {
+			distance = math.Min(distance, p.SegmentDistance(simplified[i-1], simplified[i]))
+		}
+		if distance > tolerance+1e-12 {
+			t.Errorf("%v is %v from the simplified spiral", p, distance)
+		}
+	}
+}
+
+func TestDrawingSimplify(t *testing.T) {
+	d := newDrawing([]Path{wobblyLine(), squareWithMidpoints})
+	if got := d.Simplify(Simplification{}); !reflect.DeepEqual(got, d) {
+		t.Error("no simplification changed the drawing")
+	}
+	simplified := d.Simplify(Simplification{SimplifyDouglasPeucker, 0.01})
+	if len(simplified.paths) != len(d.paths) {
+		t.Fatalf("got %d paths, want %d", len(simplified.paths), len(d.paths))
+	}
+	for i, path := range simplified.paths {
+		if path.penUp && !reflect.DeepEqual(path, d.paths[i]) {
+			t.Errorf("pen-up path %d was simplified", i)
+		}
+		if !path.penUp && len(path.Path) >= len(d.paths[i].Path) {
+			t.Errorf("pen-down path %d wasn't simplified", i)
+		}
+	}
+
+	// the simplified drawing plots in fewer moves, ending in the same place
+	plain, thinned := NewSimulator(), NewSimulator()
+	if err := PlotDrawing(plain, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PlotDrawing(thinned, d, PlotOptions{Simplify: Simplification{SimplifyVisvalingam, 0.01}}); err != nil {
+		t.Fatal(err)
+	}
+	if thinned.moves >= plain.moves || thinned.x != plain.x || thinned.y != plain.y {
+		t.Errorf("the simplified plot made %d moves to (%d, %d), and the original %d to (%d, %d)",
+			thinned.moves, thinned.x, thinned.y, plain.moves, plain.x, plain.y)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// travel limits of the AxiDraw V3, in inches
+	maxTravelX = 11.81
+	maxTravelY = 8.58
+
+	// maxStepRate is the fastest the EBB can step a single motor, in steps per second
+	maxStepRate = 25000
+
+	// simRenderScale is the number of pixels per inch used when rendering a simulated plot
+	simRenderScale = 100
+)
+
+// Simulator is a Commander that models the machine instead of talking to the
+// serial port. It keeps track of where the pen has been, how long the plot
+// takes and any moves that would exceed the machine's limits.
+type Simulator struct {
+	steppersOn bool
+	penUp      bool
+	x, y       int // position in steps
+
+	elapsed    time.Duration
+	moves      int
+	penLifts   int
+	paths      []PenPath
+	violations []string
+}
+
+func NewSimulator() *Simulator {
+	return &Simulator{penUp: true}
+}
+
+// SimulationReport summarizes everything the simulator has been asked to do.
+type SimulationReport struct {
+	TotalTime  time.Duration
+	Moves      int
+	PenLifts   int
+	Violations []string
+}
+
+func (r SimulationReport) String() string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "total time: %s\n", r.TotalTime)
+	fmt.Fprintf(out, "moves: %d\n", r.Moves)
+	fmt.Fprintf(out, "pen lifts: %d\n", r.PenLifts)
+	fmt.Fprintf(out, "violations: %d\n", len(r.Violations))
+	for _, v := range r.Violations {
+		fmt.Fprintf(out, "  %s\n", v)
+	}
+	return out.String()
+}
+
+func (s *Simulator) Report() SimulationReport {
+	return SimulationReport{
+		TotalTime:  s.elapsed,
+		Moves:      s.moves,
+		PenLifts:   s.penLifts,
+		Violations: s.violations,
+	}
+}
+
+// Reset clears everything the simulator has recorded, so that the next
+// report covers only what it's asked to do from now on. The carriage and
+// pen stay where they are.
+func (s *Simulator) Reset() {
+	s.elapsed = 0
+	s.moves = 0
+	s.penLifts = 0
+	s.paths = nil
+	s.violations = nil
+}
+
+// Render draws the simulated pen movements to a PNG.
+func (s *Simulator) Render() (*bytes.Buffer, error) {
+	return Drawing{s.paths}.Render()
+}
+
+func (s *Simulator) SteppersOn() error {
+	s.steppersOn = true
+	return nil
+}
+
+func (s *Simulator) SteppersOff() error {
+	s.steppersOn = false
+	return nil
+}
+
+func (s *Simulator) PenUp() error {
+	if !s.penUp {
+		s.penLifts++
+	}
+	s.penUp = true
+	return nil
+}
+
+func (s *Simulator) PenDown() error {
+	s.penUp = false
+	return nil
+}
+
+func (s *Simulator) Move(stepsX, stepsY int, duration time.Duration) error {
+	s.moves++
+	// the EBB enables the motors whenever it's asked to move
+	s.steppersOn = true
+
+	ms := duration.Milliseconds()
+	if ms < 1 && (stepsX != 0 || stepsY != 0) {
+		s.violate("move of (%d, %d) steps has a duration under 1ms", stepsX, stepsY)
+	}
+	if ms >= 1 {
+		// the motors of the AxiDraw's belt drive step X+Y and X-Y
+		rate1 := math.Abs(float64(stepsX+stepsY)) / duration.Seconds()
+		rate2 := math.Abs(float64(stepsX-stepsY)) / duration.Seconds()
+		if rate1 > maxStepRate || rate2 > maxStepRate {
+			s.violate("move of (%d, %d) steps in %s exceeds max step rate", stepsX, stepsY, duration)
+		}
+	}
+
+	s.advance(stepsX, stepsY, duration)
+	return nil
+}
+
+func (s *Simulator) LowLevelMove(motor1, motor2 MotorMove) error {
+	s.moves++
+	s.steppersOn = true
+
+	// the move lasts as long as the slower of the two motors takes
+	var ticks float64
+	for _, m := range []MotorMove{motor1, motor2} {
+		if m.Steps == 0 {
+			continue
+		}
+		t, ok := motorTicks(m)
+		if !ok {
+			s.violate("motor move %+v never completes its steps", m)
+			continue
+		}
+		if endRate := math.Max(float64(m.Rate), float64(m.Rate)+float64(m.Accel)*t) / lmRateScale; endRate > maxStepRate {
+			s.violate("motor move %+v exceeds max step rate", m)
+		}
+		ticks = math.Max(ticks, t)
+	}
+
+	// convert from motor steps back to the X and Y axes
+	stepsX := (motor1.Steps + motor2.Steps) / 2
+	stepsY := (motor1.Steps - motor2.Steps) / 2
+	s.advance(stepsX, stepsY, time.Duration(ticks/lmTickRate*float64(time.Second)))
+	return nil
+}
+
+func (s *Simulator) Version() (string, error) {
+	return "EBBv13_and_above EB Firmware Version 2.8.1", nil
+}
+
+// motorTicks returns the number of ticks of the step loop that an LM move
+// takes to complete its steps, or false if the motor slows to a stop first.
+func motorTicks(m MotorMove) (float64, bool) {
+	distance := math.Abs(float64(m.Steps)) * (1 << 31)
+	rate, accel := float64(m.Rate), float64(m.Accel)
+	if accel == 0 {
+		if rate <= 0 {
+			return 0, false
+		}
+		return distance / rate, true
+	}
+	// solve distance = rate*t + accel*t^2/2 for t
+	discriminant := rate*rate + 2*accel*distance
+	if discriminant < 0 {
+		return 0, false
+	}
+	return (math.Sqrt(discriminant) - rate) / accel, true
+}
+
+// advance moves the simulated carriage, checking it stays within the travel limits.
+func (s *Simulator) advance(stepsX, stepsY int, duration time.Duration) {
+	start := s.position()
+	s.x += stepsX
+	s.y += stepsY
+	s.elapsed += duration
+
+	x, y := float64(s.x)/stepsPerInch, float64(s.y)/stepsPerInch
+	if x < 0 || y < 0 || x > maxTravelX || y > maxTravelY {
+		s.violate("move to (%.3f, %.3f) is outside of the travel limits", x, y)
+	}
+	s.trace(start, s.position())
+}
+
+func (s *Simulator) Home() error {
+	start := s.position()
+	steps := math.Hypot(float64(s.x), float64(s.y))
+	s.elapsed += time.Duration(steps / homeStepRate * float64(time.Second))
+	s.x, s.y = 0, 0
+	s.trace(start, s.position())
+	return nil
+}
+
+func (s *Simulator) QueryButton() (bool, error) {
+	return false, nil
+}
+
+// QueryMotion always reports the simulator as idle, since its moves complete instantly.
+func (s *Simulator) QueryMotion() (MotionStatus, error) {
+	return MotionStatus{}, nil
+}
+
+func (s *Simulator) Raw(command ...string) (string, error) {
+	return "OK\r\n", nil
+}
+
+// position returns the current position scaled for rendering.
+func (s *Simulator) position() Vec2d {
+	return Vec2d{
+		x: float64(s.x) / stepsPerInch * simRenderScale,
+		y: float64(s.y) / stepsPerInch * simRenderScale,
+	}
+}
+
+// trace records a move, extending the last path if the pen state hasn't changed.
+func (s *Simulator) trace(from, to Vec2d) {
+	if n := len(s.paths); n > 0 && s.paths[n-1].penUp == s.penUp {
+		s.paths[n-1].Path = append(s.paths[n-1].Path, to)
+		return
+	}
+	s.paths = append(s.paths, PenPath{Path{from, to}, s.penUp})
+}
+
+func (s *Simulator) violate(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	s.violations = append(s.violations, fmt.Sprintf("move %d: %s", s.moves, msg))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulatorStepRate(t *testing.T) {
+	tests := []struct {
+		stepsX, stepsY int
+		violates       bool
+	}{
+		{2000, 0, false},
+		{0, -2000, false},
+		// each axis is within the limit, but one of the motors steps both
+		{2000, 2000, true},
+		{2000, -2000, true},
+		{1200, 1200, false},
+		{3000, 0, true},
+	}
+	for _, test := range tests {
+		sim := NewSimulator()
+		// start in the middle, so that no move leaves the travel limits
+		sim.x, sim.y = 10000, 8000
+		if err := sim.Move(test.stepsX, test.stepsY, 100*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		if violates := len(sim.Report().Violations) > 0; violates != test.violates {
+			t.Errorf("move of (%d, %d) steps in 100ms: got violations %v, want %v", test.stepsX, test.stepsY, sim.Report().Violations, test.violates)
+		}
+	}
+}
+
+func TestSimulatorPenLiftsMatchStats(t *testing.T) {
+	d := newDrawing([]Path{square(1, 1, 1), square(3, 1, 1), {{5, 1}, {6, 2}}})
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// the pen is lifted after each path, including the last
+	if lifts, stats := sim.Report().PenLifts, d.Stats(); lifts != 3 || stats.PenLifts != lifts {
+		t.Errorf("the simulator counted %d pen lifts and the stats %d, want 3", lifts, stats.PenLifts)
+	}
+}
+
+func TestSimulatorDryRunReport(t *testing.T) {
+	d := newDrawing([]Path{square(1, 1, 1)})
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	report := sim.Report()
+	if report.PenLifts != 1 || report.Moves == 0 || len(report.Violations) != 0 {
+		t.Errorf("got %+v, want one pen lift, some moves and no violations", report)
+	}
+	// the moves are rounded to whole milliseconds, so the time is close to what's planned
+	planned := d.Stats().TotalTime
+	if diff := (report.TotalTime - planned).Seconds(); math.Abs(diff) > 0.05*planned.Seconds() {
+		t.Errorf("the plot took %s, want about the planned %s", report.TotalTime, planned)
+	}
+	// the square was drawn, and the carriage stopped where it finished
+	var drawn []Vec2d
+	for _, path := range sim.paths {
+		if !path.penUp {
+			for _, p := range path.Path {
+				drawn = append(drawn, p.Multiply(1.0/simRenderScale))
+			}
+		}
+	}
+	for _, corner := range square(1, 1, 1) {
+		if !hasPointNear(drawn, corner, 0.001) {
+			t.Errorf("the simulated plot didn't reach %v", corner)
+		}
+	}
+	if !sim.penUp || math.Abs(float64(sim.x)-stepsPerInch) > 1 || math.Abs(float64(sim.y)-stepsPerInch) > 1 {
+		t.Errorf("the plot ended at (%d, %d) steps with the pen up %v, want (%d, %d) with it up", sim.x, sim.y, sim.penUp, stepsPerInch, stepsPerInch)
+	}
+	if _, err := sim.Render(); err != nil {
+		t.Error(err)
+	}
+
+	// the next plot is reported on its own once the simulator is reset
+	sim.Reset()
+	if report := sim.Report(); report.TotalTime != 0 || report.Moves != 0 || report.PenLifts != 0 || len(sim.paths) != 0 {
+		t.Errorf("got %+v after a reset, want nothing", report)
+	}
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if again := sim.Report(); again.Moves == 0 || again.PenLifts != 1 {
+		t.Errorf("got %+v for the second plot, want it reported on its own", again)
+	}
+}
+
+func TestSimulatorTravelLimits(t *testing.T) {
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, newDrawing([]Path{{{1, 1}, {maxTravelX + 1, 1}}}), PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sim.Report().Violations) == 0 {
+		t.Error("a path past the travel limits wasn't reported")
+	}
+}
+
+// hasPointNear reports whether any of the points is within the distance of p.
+func hasPointNear(points []Vec2d, p Vec2d, distance float64) bool {
+	for _, q := range points {
+		if q.Distance(p) <= distance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+)
+
+const (
+	defaultSpecimenColumns  = 10
+	defaultSpecimenCellSize = 0.75
+
+	// the glyph takes up the top of each cell, and its labels the bottom
+	specimenGlyphSize = 0.5
+	specimenLabelSize = 0.11
+	specimenPadding   = 0.06
+
+	// outline fonts can have thousands of glyphs, so their specimens show
+	// printable ASCII unless they're given a range
+	specimenOutlineFirst = ' '
+	specimenOutlineLast  = '~'
+)
+
+// SpecimenOptions controls the layout of a specimen sheet. Lengths are in inches.
+type SpecimenOptions struct {
+	// Title is written above the grid, if it's set.
+	Title string
+	// Columns is the number of cells in each row of the grid.
+	Columns int
+	// CellSize is the width and height of each cell.
+	CellSize float64
+	// Origin is the top left corner of the sheet on the page.
+	Origin Vec2d
+	// LabelFont is the font that the title and labels are written in.
+	LabelFont Font
+	// First and Last are the range of characters shown. If they're both
+	// zero, every character is shown, or printable ASCII for outline fonts.
+	// If only Last is zero, every character from First on is shown.
+	First, Last rune
+}
+
+// DefaultSpecimenOptions lays out ten 3/4 inch cells per row, labelled in futural.
+var DefaultSpecimenOptions = SpecimenOptions{
+	Columns:   defaultSpecimenColumns,
+	CellSize:  defaultSpecimenCellSize,
+	Origin:    Vec2d{1, 1},
+	LabelFont: FontFutural,
+}
+
+// chars returns every character from first to last that the font has a glyph for, in order.
+func (font Font) chars(first, last rune) []rune {
+	var out []rune
+	add := func(ch rune) {
+		if ch >= first && ch <= last {
+			out = append(out, ch)
+		}
+	}
+	for i := range font.glyphs {
+		add(rune(' ' + i))
+	}
+	for ch := range font.runes {
+		if int(ch)-' ' < 0 || int(ch)-' ' >= len(font.glyphs) {
+			add(ch)
+		}
+	}
+	if font.outline != nil {
+		for _, ch := range font.outline.chars {
+			add(ch)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// FontSpecimen draws the glyphs in the font in a grid, one per cell. Each
+// glyph stands between vertical lines at its left and right bearings, over
+// a line along its baseline, and it's labelled with its character code and
+// the positions of its bearings in font units. That shows what the font
+// holds at each code, which is the only way to find the symbols in fonts
+// like FontAstrology that put them in place of letters.
+func FontSpecimen(font Font, opts SpecimenOptions) Drawing {
+	if opts.Columns <= 0 {
+		opts.Columns = defaultSpecimenColumns
+	}
+	if opts.CellSize <= 0 {
+		opts.CellSize = defaultSpecimenCellSize
+	}
+	first, last := opts.First, opts.Last
+	if first == 0 && last == 0 && font.outline != nil {
+		first, last = specimenOutlineFirst, specimenOutlineLast
+	} else if last == 0 {
+		last = unicode.MaxRune
+	}
+	cell := opts.CellSize
+	label := TextLayout{Font: opts.LabelFont, Size: cell * specimenLabelSize}
+	scale := cell * specimenGlyphSize / hersheyUnitsPerEm
+
+	var paths []Path
+	top := opts.Origin.y
+	if opts.Title != "" {
+		title := label
+		title.Size = cell * specimenLabelSize * 2
+		title.Origin = opts.Origin
+		paths = append(paths, title.Layout(opts.Title).penDownPaths()...)
+		top += title.Size * 1.5
+	}
+
+	for i, ch := range font.chars(first, last) {
+		glyph, _ := font.glyph(ch)
+		corner := Vec2d{
+			opts.Origin.x + float64(i%opts.Columns)*cell,
+			top + float64(i/opts.Columns)*cell,
+		}
+		paths = append(paths, Path{
+			corner,
+			corner.Add(Vec2d{cell, 0}),
+			corner.Add(Vec2d{cell, cell}),
+			corner.Add(Vec2d{0, cell}),
+			corner,
+		})
+
+		// center the glyph's advance across the cell, with its em square at the top
+		width := (glyph.right - glyph.left) * scale
+		origin := Vec2d{
+			corner.x + (cell-width)/2 - glyph.left*scale,
+			corner.y + specimenPadding*cell - hersheyEmTop*scale,
+		}
+		place := func(x, y float64) Vec2d {
+			return Vec2d{origin.x + x*scale, origin.y + y*scale}
+		}
+		for _, path := range glyph.paths {
+			placed := make(Path, 0, len(path))
+			for _, point := range path {
+				placed = append(placed, place(point.x, point.y))
+			}
+			paths = append(paths, placed)
+		}
+		emBottom := float64(-hersheyEmTop)
+		paths = append(paths,
+			Path{place(glyph.left, hersheyEmTop), place(glyph.left, emBottom)},
+			Path{place(glyph.right, hersheyEmTop), place(glyph.right, emBottom)},
+			Path{place(glyph.left, hersheyBaseline), place(glyph.right, hersheyBaseline)},
+		)
+
+		label.Origin = Vec2d{
+			corner.x + specimenPadding*cell,
+			corner.y + cell*(1-specimenPadding) - 2*label.Size,
+		}
+		text := fmt.Sprintf("U+%04X\n%.4g %.4g", ch, glyph.left, glyph.right)
+		paths = append(paths, label.Layout(text).penDownPaths()...)
+	}
+	return newDrawing(paths)
+}
+
+// penDownPaths returns the paths that are drawn, leaving out the travel between them.
+func (d Drawing) penDownPaths() []Path {
+	var out []Path
+	for _, path := range d.paths {
+		if !path.penUp {
+			out = append(out, path.Path)
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestFontChars(t *testing.T) {
+	font := Font{
+		glyphs: []Glyph{{}, {}, {}},
+		runes:  map[rune]Glyph{'é': {}, '"': {}, 'A': {}},
+	}
+	if got, want := font.chars(0, 0x10ffff), []rune(" !\"Aé"); !reflect.DeepEqual(got, want) {
+		t.Errorf("chars = %q, want %q", got, want)
+	}
+	if got, want := font.chars('!', 'A'), []rune("!\"A"); !reflect.DeepEqual(got, want) {
+		t.Errorf("chars from ! to A = %q, want %q", got, want)
+	}
+}
+
+func TestOutlineFontSpecimen(t *testing.T) {
+	font, err := LoadOutlineFont(bytes.NewReader(goregular.TTF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	FontSpecimen(font, DefaultSpecimenOptions)
+	// only the glyphs that are shown are loaded
+	if n := len(font.outline.glyphs); n != specimenOutlineLast-specimenOutlineFirst+1 {
+		t.Errorf("%d glyphs were loaded for the default specimen, want printable ASCII", n)
+	}
+	opts := DefaultSpecimenOptions
+	opts.First, opts.Last = 'é', 'é'
+	FontSpecimen(font, opts)
+	if _, ok := font.outline.glyphs['é']; !ok {
+		t.Error("é wasn't loaded for a specimen of it")
+	}
+}
+
+func TestParseRuneRange(t *testing.T) {
+	tests := []struct {
+		input       string
+		first, last rune
+		ok          bool
+	}{
+		{"U+0041-U+005A", 'A', 'Z', true},
+		{"20-7e", ' ', '~', true},
+		{"u+e9-u+e9", 'é', 'é', true},
+		{"5A-41", 0, 0, false},
+		{"futura-medium", 0, 0, false},
+		{"0041", 0, 0, false},
+		{"0-110000", 0, 0, false},
+	}
+	for _, test := range tests {
+		first, last, ok := parseRuneRange(test.input)
+		if first != test.first || last != test.last || ok != test.ok {
+			t.Errorf("parseRuneRange(%q) = %q, %q, %v, want %q, %q, %v", test.input, first, last, ok, test.first, test.last, test.ok)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// svgDefaultUnitsPerEm is the size of the em square of an SVG font without a <font-face>.
+const svgDefaultUnitsPerEm = 1000
+
+// svgGlyph is a <glyph> or <hkern> as it appears in an SVG font, in the font's own units.
+type svgGlyph struct {
+	unicode, name, d string
+	advance          float64
+}
+
+type svgKern struct {
+	u1, u2, g1, g2 string
+	k              float64
+}
+
+// LoadSVGFont reads the first font defined in an SVG document, such as the
+// single-stroke fonts that ship with Inkscape's Hershey Text extension. The
+// glyphs are scaled so that the font's em square is the same size as a
+// Hershey font's and flipped so that y runs down the page, with the baseline
+// where Hershey fonts put it. Curves are flattened finely enough for text an
+// inch high. Glyphs without a character, or for more than one character
+// like ligatures, are skipped, and <hkern> pairs become the font's kerning.
+func LoadSVGFont(r io.Reader) (Font, error) {
+	decoder := xml.NewDecoder(r)
+	unitsPerEm := float64(svgDefaultUnitsPerEm)
+	var defaultAdvance float64
+	var glyphs []svgGlyph
+	var kerns []svgKern
+	var inFont, sawFont bool
+
+	parse := func(e xml.StartElement, name string) (float64, error) {
+		value := svgAttr(e, name)
+		if value == "" {
+			return 0, nil
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s on <%s>: %q", name, e.Name.Local, value)
+		}
+		return v, nil
+	}
+
+tokens:
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Font{}, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "font" {
+				inFont, sawFont = true, true
+				if defaultAdvance, err = parse(t, "horiz-adv-x"); err != nil {
+					return Font{}, err
+				}
+				continue
+			}
+			if !inFont {
+				continue
+			}
+			switch t.Name.Local {
+			case "font-face":
+				v, err := parse(t, "units-per-em")
+				if err != nil {
+					return Font{}, err
+				}
+				if v > 0 {
+					unitsPerEm = v
+				}
+			case "glyph":
+				advance, err := parse(t, "horiz-adv-x")
+				if err != nil {
+					return Font{}, err
+				}
+				glyphs = append(glyphs, svgGlyph{
+					unicode: svgAttr(t, "unicode"),
+					name:    svgAttr(t, "glyph-name"),
+					d:       svgAttr(t, "d"),
+					advance: advance,
+				})
+			case "hkern":
+				k, err := parse(t, "k")
+				if err != nil {
+					return Font{}, err
+				}
+				kerns = append(kerns, svgKern{
+					u1: svgAttr(t, "u1"), u2: svgAttr(t, "u2"),
+					g1: svgAttr(t, "g1"), g2: svgAttr(t, "g2"),
+					k: k,
+				})
+			}
+		case xml.EndElement:
+			if t.Name.Local == "font" && inFont {
+				break tokens
+			}
+		}
+	}
+	if !sawFont {
+		return Font{}, fmt.Errorf("no <font> found")
+	}
+
+	scale := hersheyUnitsPerEm / unitsPerEm
+	transform := func(p Vec2d) Vec2d {
+		return Vec2d{p.x * scale, hersheyBaseline - p.y*scale}
+	}
+	font := Font{runes: map[rune]Glyph{}, kerning: map[[2]rune]float64{}}
+	names := map[string]rune{}
+	for _, g := range glyphs {
+		// glyphs without a character, like .notdef, can't be typed, and
+		// glyphs for more than one are ligatures
+		runes := []rune(g.unicode)
+		if len(runes) != 1 {
+			continue
+		}
+		advance := g.advance
+		if advance == 0 {
+			advance = defaultAdvance
+		}
+		// a glyph without path data, like a space, only moves the next one along
+		var paths []Path
+		if strings.TrimSpace(g.d) != "" {
+			var err error
+			paths, err = ParseSVGPath(g.d, transform, DefaultCurveTolerance*hersheyUnitsPerEm)
+			if err != nil {
+				return Font{}, fmt.Errorf("glyph %q: %w", g.unicode, err)
+			}
+		}
+		font.runes[runes[0]] = Glyph{left: 0, right: advance * scale, paths: paths}
+		if g.name != "" {
+			names[g.name] = runes[0]
+		}
+	}
+	if _, ok := font.runes[' ']; !ok {
+		advance := defaultAdvance
+		if advance == 0 {
+			advance = unitsPerEm / 3
+		}
+		font.runes[' '] = Glyph{left: 0, right: advance * scale}
+	}
+
+	for _, k := range kerns {
+		firsts := append(svgKernRunes(k.u1), svgKernNames(k.g1, names)...)
+		seconds := append(svgKernRunes(k.u2), svgKernNames(k.g2, names)...)
+		for _, a := range firsts {
+			for _, b := range seconds {
+				// a positive k brings the glyphs closer together
+				font.kerning[[2]rune{a, b}] = -k.k * scale
+			}
+		}
+	}
+	return font, nil
+}
+
+func svgAttr(e xml.StartElement, name string) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// svgKernRunes returns the characters in the u1 or u2 attribute of an
+// <hkern>, a comma separated list of characters and Unicode ranges such as
+// "U+0041-005A".
+func svgKernRunes(list string) []rune {
+	var out []rune
+	for _, item := range strings.Split(list, ",") {
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "U+") && len(item) > 2 {
+			first, last := item[2:], item[2:]
+			if i := strings.IndexByte(first, '-'); i >= 0 {
+				first, last = first[:i], first[i+1:]
+			}
+			lo, err1 := strconv.ParseInt(first, 16, 32)
+			hi, err2 := strconv.ParseInt(last, 16, 32)
+			if err1 == nil && err2 == nil {
+				for r := lo; r <= hi; r++ {
+					out = append(out, rune(r))
+				}
+				continue
+			}
+		}
+		if runes := []rune(item); len(runes) == 1 {
+			out = append(out, runes[0])
+		}
+	}
+	return out
+}
+
+// svgKernNames returns the characters of the glyphs named in the g1 or g2 attribute of an <hkern>.
+func svgKernNames(list string, names map[string]rune) []rune {
+	var out []rune
+	for _, name := range strings.Split(list, ",") {
+		if r, ok := names[strings.TrimSpace(name)]; ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
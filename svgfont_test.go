@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSVGFont = `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg">
+<defs>
+<font id="test" horiz-adv-x="500">
+<font-face units-per-em="1000"/>
+<glyph unicode=" "/>
+<glyph unicode="A" glyph-name="A" horiz-adv-x="600" d="M0 0L300 700L600 0"/>
+<glyph unicode="V" glyph-name="vee" d="M0 700L250 0L500 700"/>
+<glyph unicode="-" d=""/>
+<glyph unicode="" d="M0 0L100 100"/>
+<glyph glyph-name=".notdef" d="M0 0L100 100"/>
+<glyph unicode="fi" d="M0 0L100 100"/>
+<hkern u1="A" g2="vee" k="80"/>
+<hkern u1="U+0041-0042" u2="A" k="-10"/>
+</font>
+</defs>
+</svg>`
+
+func TestLoadSVGFont(t *testing.T) {
+	font, err := LoadSVGFont(strings.NewReader(testSVGFont))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// glyphs without path data are kept, and those without one character are skipped
+	if font.count() != 4 {
+		t.Errorf("got %d glyphs, want 4", font.count())
+	}
+	const scale = hersheyUnitsPerEm / 1000.0
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	space, ok := font.glyph(' ')
+	if !ok || !near(space.right, 500*scale) || len(space.paths) != 0 {
+		t.Errorf("space = %+v, want the font's default advance and no paths", space)
+	}
+	dash, ok := font.glyph('-')
+	if !ok || !near(dash.right, 500*scale) || len(dash.paths) != 0 {
+		t.Errorf("dash = %+v, want the font's default advance and no paths", dash)
+	}
+
+	a, ok := font.glyph('A')
+	if !ok || !near(a.right, 600*scale) || len(a.paths) != 1 || len(a.paths[0]) != 3 {
+		t.Fatalf("A = %+v, want an advance of 600 units and one path of 3 points", a)
+	}
+	// flipped so that y runs down, with the font's baseline on the Hershey baseline
+	if top := a.paths[0][1]; !near(top.x, 300*scale) || !near(top.y, hersheyBaseline-700*scale) {
+		t.Errorf("apex of A is at %v", top)
+	}
+	if base := a.paths[0][0]; !near(base.y, hersheyBaseline) {
+		t.Errorf("foot of A is at %v, want it on the baseline", base)
+	}
+
+	if k := font.kern('A', 'V'); !near(k, -80*scale) {
+		t.Errorf("kern(A, V) = %v, want %v", k, -80*scale)
+	}
+	if k := font.kern('B', 'A'); !near(k, 10*scale) {
+		t.Errorf("kern(B, A) = %v, want %v", k, 10*scale)
+	}
+	if k := font.kern('V', 'A'); k != 0 {
+		t.Errorf("kern(V, A) = %v, want 0", k)
+	}
+}
+
+func TestLoadSVGFontErrors(t *testing.T) {
+	tests := map[string]string{
+		"no font":             `<svg><path d="M0 0L1 1"/></svg>`,
+		"invalid advance":     `<svg><font horiz-adv-x="wide"></font></svg>`,
+		"invalid path data":   `<svg><font><glyph unicode="a" d="M0 0 L1"/></font></svg>`,
+		"invalid kerning":     `<svg><font><hkern u1="a" u2="b" k="x"/></font></svg>`,
+		"invalid units":       `<svg><font><font-face units-per-em="lots"/></font></svg>`,
+		"malformed document":  `<svg><font>`,
+		"unclosed attributes": `<svg><font horiz-adv-x="1></svg>`,
+	}
+	for name, data := range tests {
+		if _, err := LoadSVGFont(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSVGKernRunes(t *testing.T) {
+	if got, want := svgKernRunes("a,U+0041-0043,U+005A,"), []rune("aABCZ"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// transformedCurve is a curve with each of its points passed through a transform.
+type transformedCurve struct {
+	Curve
+	transform func(Vec2d) Vec2d
+}
+
+func (c transformedCurve) At(t float64) Vec2d {
+	return c.transform(c.Curve.At(t))
+}
+
+// ParseSVGPath parses SVG path data, as found in the d attribute of a
+// <path> or <glyph>, into a path for each subpath. Every point is passed
+// through the transform, and curves are flattened to within tolerance of
+// the transformed curve.
+func ParseSVGPath(d string, transform func(Vec2d) Vec2d, tolerance float64) ([]Path, error) {
+	s := &svgPathScanner{data: d}
+	var paths []Path
+	var path Path
+	var current, start, control Vec2d
+	var command, previous byte
+
+	finish := func() {
+		if len(path) > 1 {
+			paths = append(paths, path)
+		}
+		path = nil
+	}
+	lineTo := func(p Vec2d) {
+		if path == nil {
+			path = Path{transform(current)}
+		}
+		path = append(path, transform(p))
+		current = p
+	}
+	curveTo := func(c Curve, end Vec2d) {
+		if path == nil {
+			path = Path{transform(current)}
+		}
+		flattened := Flatten(transformedCurve{c, transform}, tolerance)
+		path = append(path, flattened[1:]...)
+		current = end
+	}
+
+	for {
+		s.skipSeparators()
+		if s.done() {
+			break
+		}
+		if c := s.peek(); c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+			command = c
+			s.pos++
+		} else if command == 0 {
+			return nil, fmt.Errorf("expected a command in path data at offset %d", s.pos)
+		}
+		relative := command >= 'a'
+		point := func() (Vec2d, error) {
+			x, err := s.number()
+			if err != nil {
+				return Vec2d{}, err
+			}
+			y, err := s.number()
+			if err != nil {
+				return Vec2d{}, err
+			}
+			if relative {
+				return current.Add(Vec2d{x, y}), nil
+			}
+			return Vec2d{x, y}, nil
+		}
+		points := func(n int) ([]Vec2d, error) {
+			out := make([]Vec2d, n)
+			for i := range out {
+				p, err := point()
+				if err != nil {
+					return nil, err
+				}
+				out[i] = p
+			}
+			return out, nil
+		}
+
+		upper := command &^ ('a' - 'A')
+		var err error
+		switch upper {
+		case 'M':
+			var p Vec2d
+			if p, err = point(); err != nil {
+				break
+			}
+			finish()
+			current, start = p, p
+			path = Path{transform(p)}
+			// any further pairs of coordinates are lines
+			command -= 'M' - 'L'
+		case 'L':
+			var p Vec2d
+			if p, err = point(); err == nil {
+				lineTo(p)
+			}
+		case 'H', 'V':
+			var v float64
+			if v, err = s.number(); err != nil {
+				break
+			}
+			p := current
+			if upper == 'H' {
+				p.x = v
+				if relative {
+					p.x += current.x
+				}
+			} else {
+				p.y = v
+				if relative {
+					p.y += current.y
+				}
+			}
+			lineTo(p)
+		case 'C', 'S':
+			n := 3
+			if upper == 'S' {
+				n = 2
+			}
+			var ps []Vec2d
+			if ps, err = points(n); err != nil {
+				break
+			}
+			if upper == 'S' {
+				// the first control point reflects the last one of a preceding cubic
+				reflected := current
+				if previous == 'C' || previous == 'S' {
+					reflected = current.Multiply(2).Subtract(control)
+				}
+				ps = append([]Vec2d{reflected}, ps...)
+			}
+			curveTo(CubicBezier{current, ps[0], ps[1], ps[2]}, ps[2])
+			control = ps[1]
+		case 'Q', 'T':
+			var ps []Vec2d
+			if upper == 'Q' {
+				ps, err = points(2)
+			} else {
+				var p Vec2d
+				p, err = point()
+				reflected := current
+				if previous == 'Q' || previous == 'T' {
+					reflected = current.Multiply(2).Subtract(control)
+				}
+				ps = []Vec2d{reflected, p}
+			}
+			if err != nil {
+				break
+			}
+			curveTo(QuadraticBezier{current, ps[0], ps[1]}, ps[1])
+			control = ps[0]
+		case 'A':
+			var rx, ry, rotation float64
+			var large, sweep bool
+			var p Vec2d
+			if rx, err = s.number(); err != nil {
+				break
+			}
+			if ry, err = s.number(); err != nil {
+				break
+			}
+			if rotation, err = s.number(); err != nil {
+				break
+			}
+			if large, err = s.flag(); err != nil {
+				break
+			}
+			if sweep, err = s.flag(); err != nil {
+				break
+			}
+			if p, err = point(); err != nil {
+				break
+			}
+			if arc, ok := svgArc(current, p, rx, ry, rotation*math.Pi/180, large, sweep); ok {
+				curveTo(arc, p)
+			} else if p != current {
+				lineTo(p)
+			}
+		case 'Z':
+			lineTo(start)
+			finish()
+			// a number can't follow a close path without a new command
+			command = 0
+		default:
+			err = fmt.Errorf("unknown path command %q", command)
+		}
+		if err != nil {
+			return nil, err
+		}
+		previous = upper
+	}
+	finish()
+	return paths, nil
+}
+
+// svgArc converts an SVG elliptical arc, given by its end points, radii and
+// flags, to the center parameterization of Arc, as described in the
+// implementation notes of the SVG specification. It returns false if the arc
+// is degenerate and should be drawn as a straight line, or not at all.
+func svgArc(p0, p1 Vec2d, rx, ry, rotation float64, large, sweep bool) (Arc, bool) {
+	if p0 == p1 || rx == 0 || ry == 0 {
+		return Arc{}, false
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	sin, cos := math.Sincos(rotation)
+	dx, dy := (p0.x-p1.x)/2, (p0.y-p1.y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	// scale the radii up if they're too small to reach between the points
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	center := Vec2d{
+		cos*cx1 - sin*cy1 + (p0.x+p1.x)/2,
+		sin*cx1 + cos*cy1 + (p0.y+p1.y)/2,
+	}
+
+	angle := func(u, v Vec2d) float64 {
+		return math.Atan2(u.x*v.y-u.y*v.x, u.Dot(v))
+	}
+	u := Vec2d{(x1 - cx1) / rx, (y1 - cy1) / ry}
+	v := Vec2d{(-x1 - cx1) / rx, (-y1 - cy1) / ry}
+	start, delta := angle(Vec2d{1, 0}, u), angle(u, v)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+	return Arc{Center: center, Radius: Vec2d{rx, ry}, Rotation: rotation, Start: start, Sweep: delta}, true
+}
+
+// svgPathScanner reads the numbers and flags of SVG path data.
+type svgPathScanner struct {
+	data string
+	pos  int
+}
+
+func (s *svgPathScanner) done() bool {
+	return s.pos >= len(s.data)
+}
+
+func (s *svgPathScanner) peek() byte {
+	return s.data[s.pos]
+}
+
+func (s *svgPathScanner) skipSeparators() {
+	for !s.done() && strings.IndexByte(" \t\r\n,", s.peek()) >= 0 {
+		s.pos++
+	}
+}
+
+// number reads a number, which may run straight on from the previous one
+// if it starts with a sign or a second decimal point, as in "1-2" or "0.5.5".
+func (s *svgPathScanner) number() (float64, error) {
+	s.skipSeparators()
+	start := s.pos
+	if !s.done() && (s.peek() == '-' || s.peek() == '+') {
+		s.pos++
+	}
+	digits := func() {
+		for !s.done() && s.peek() >= '0' && s.peek() <= '9' {
+			s.pos++
+		}
+	}
+	digits()
+	if !s.done() && s.peek() == '.' {
+		s.pos++
+		digits()
+	}
+	if !s.done() && (s.peek() == 'e' || s.peek() == 'E') {
+		s.pos++
+		if !s.done() && (s.peek() == '-' || s.peek() == '+') {
+			s.pos++
+		}
+		digits()
+	}
+	v, err := strconv.ParseFloat(s.data[start:s.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in path data at offset %d", start)
+	}
+	return v, nil
+}
+
+// flag reads an arc flag, which is a single 0 or 1 that needn't be separated from what follows.
+func (s *svgPathScanner) flag() (bool, error) {
+	s.skipSeparators()
+	if s.done() || s.peek() != '0' && s.peek() != '1' {
+		return false, fmt.Errorf("invalid arc flag in path data at offset %d", s.pos)
+	}
+	s.pos++
+	return s.data[s.pos-1] == '1', nil
+}
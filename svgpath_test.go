@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func identity(p Vec2d) Vec2d { return p }
+
+func TestParseSVGPathLines(t *testing.T) {
+	tests := []struct {
+		d    string
+		want []Path
+	}{
+		{"M0 0 L10 0 L10 10 Z", []Path{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}}},
+		{"m1 1 2 0 0 2", []Path{{{1, 1}, {3, 1}, {3, 3}}}},
+		{"M0 0H5V5h-5z", []Path{{{0, 0}, {5, 0}, {5, 5}, {0, 5}, {0, 0}}}},
+		{"M0 0L1 0M2 0L3 0", []Path{{{0, 0}, {1, 0}}, {{2, 0}, {3, 0}}}},
+		{"M0-1L.5.5", []Path{{{0, -1}, {0.5, 0.5}}}},
+		{"M1e1,0 l-1E1,0", []Path{{{10, 0}, {0, 0}}}},
+		{"M0 0", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got, err := ParseSVGPath(test.d, identity, DefaultCurveTolerance)
+		if err != nil {
+			t.Errorf("%q: %s", test.d, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.d, got, test.want)
+		}
+	}
+}
+
+func TestParseSVGPathCurves(t *testing.T) {
+	const tolerance = 0.001
+	tests := []struct {
+		name, d    string
+		end        Vec2d
+		minY, maxY float64
+	}{
+		{"cubic", "M0 0C0 10 10 10 10 0", Vec2d{10, 0}, 0, 7.5},
+		{"smooth cubic", "M0 0C0 1 1 1 1 0S2 -1 2 0", Vec2d{2, 0}, -0.75, 0.75},
+		{"quadratic", "M0 0Q1 2 2 0", Vec2d{2, 0}, 0, 1},
+		{"smooth quadratic", "M0 0Q1 2 2 0T4 0", Vec2d{4, 0}, -1, 1},
+		{"relative cubic", "m1 1c0 10 10 10 10 0", Vec2d{11, 1}, 1, 8.5},
+	}
+	for _, test := range tests {
+		paths, err := ParseSVGPath(test.d, identity, tolerance)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if len(paths) != 1 {
+			t.Errorf("%s: got %d paths, want 1", test.name, len(paths))
+			continue
+		}
+		path := paths[0]
+		if end := path[len(path)-1]; end.Distance(test.end) > 1e-9 {
+			t.Errorf("%s: ends at %v, want %v", test.name, end, test.end)
+		}
+		minY, maxY := math.Inf(1), math.Inf(-1)
+		for _, p := range path {
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+		}
+		if math.Abs(minY-test.minY) > tolerance || math.Abs(maxY-test.maxY) > tolerance {
+			t.Errorf("%s: y runs from %v to %v, want %v to %v", test.name, minY, maxY, test.minY, test.maxY)
+		}
+	}
+}
+
+func TestParseSVGPathArc(t *testing.T) {
+	for _, d := range []string{"M0 0A1 1 0 0 1 2 0", "M0 0a1 1 0 0 0 2 0", "M0 0A1 1 0 012 0"} {
+		paths, err := ParseSVGPath(d, identity, 0.001)
+		if err != nil {
+			t.Errorf("%q: %s", d, err)
+			continue
+		}
+		if len(paths) != 1 || len(paths[0]) < 3 {
+			t.Errorf("%q: got %v, want a flattened half circle", d, paths)
+			continue
+		}
+		for _, p := range paths[0] {
+			if r := p.Distance(Vec2d{1, 0}); math.Abs(r-1) > 0.001 {
+				t.Errorf("%q: point %v is %v from the center, want 1", d, p, r)
+				break
+			}
+		}
+	}
+
+	// radii that are too small to reach are scaled up, and zero radii make a line
+	paths, err := ParseSVGPath("M0 0A0.1 0.1 0 0 1 2 0", identity, 0.001)
+	if err != nil || len(paths) != 1 || paths[0][len(paths[0])/2].Distance(Vec2d{1, 0}) < 0.999 {
+		t.Errorf("arc with small radii: got %v, %v", paths, err)
+	}
+	paths, err = ParseSVGPath("M0 0A0 1 0 0 1 2 0", identity, 0.001)
+	if err != nil || !reflect.DeepEqual(paths, []Path{{{0, 0}, {2, 0}}}) {
+		t.Errorf("arc with a zero radius: got %v, %v", paths, err)
+	}
+}
+
+func TestParseSVGPathTransform(t *testing.T) {
+	double := func(p Vec2d) Vec2d { return p.Multiply(2) }
+	paths, err := ParseSVGPath("M1 1l1 0", double, DefaultCurveTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Path{{{2, 2}, {4, 2}}}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+}
+
+func TestParseSVGPathErrors(t *testing.T) {
+	for _, d := range []string{"1 1", "M0", "M0 0 L1", "M0 0 A1 1 0 2 1 1 1", "M0 0 X1 1", "M0 0 Z 1 1", "M0 0 L1 x"} {
+		if paths, err := ParseSVGPath(d, identity, DefaultCurveTolerance); err == nil {
+			t.Errorf("%q: expected an error, got %v", d, paths)
+		}
+	}
+}
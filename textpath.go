@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// pathEpsilon is the distance below which points on a path are taken to coincide.
+const pathEpsilon = 1e-9
+
+// PathTextOptions controls how text is set along a path with TextLayout.AlongPath.
+type PathTextOptions struct {
+	// Offset moves the text along the path, away from the end it's aligned
+	// to: forwards from the start for left and justified text, backwards from
+	// the end for right aligned text, and forwards from the middle for
+	// centered text.
+	Offset float64
+	// Inside puts the glyphs on the other side of the path, reading along it
+	// from its end. On the bottom of a circle that's drawn clockwise, this
+	// sets text that reads left to right with its tops towards the center.
+	Inside bool
+}
+
+// AlongPath sets the input on the path as its baseline, rotating each glyph
+// to follow the direction of the path where it sits. By default glyphs
+// stand on the left of the path as it's followed, so text on the top of a
+// circle drawn clockwise is on the outside. The layout's font, fallbacks,
+// size, letter spacing and alignment are used; justified text is spread to
+// fill the path. Newlines are set as spaces.
+func (l TextLayout) AlongPath(input string, baseline Path, opts PathTextOptions) Drawing {
+	if len(baseline) < 2 {
+		return newDrawing(nil)
+	}
+	if opts.Inside {
+		baseline = baseline.reversed()
+	}
+	scale := l.Size / hersheyUnitsPerEm
+
+	var glyphs []Glyph
+	var kerning []float64
+	var textWidth float64
+	var prev rune
+	var prevSource int
+	for _, ch := range strings.ReplaceAll(input, "\n", " ") {
+		glyph, source := l.glyphAt(ch, len(glyphs))
+		kern := l.kern(prev, ch, prevSource, source) * scale
+		glyphs = append(glyphs, glyph)
+		kerning = append(kerning, kern)
+		textWidth += kern + l.advance(glyph, scale)
+		prev, prevSource = ch, source
+	}
+	if len(glyphs) == 0 {
+		return newDrawing(nil)
+	}
+	textWidth -= l.LetterSpacing
+
+	measured := newMeasuredPath(baseline)
+	length := measured.length()
+	var start, extra float64
+	switch l.Align {
+	case AlignLeft:
+		start = opts.Offset
+	case AlignCenter:
+		start = (length-textWidth)/2 + opts.Offset
+	case AlignRight:
+		start = length - textWidth - opts.Offset
+	case AlignJustify:
+		start = opts.Offset
+		if len(glyphs) > 1 && textWidth < length-opts.Offset {
+			extra = (length - opts.Offset - textWidth) / float64(len(glyphs)-1)
+		}
+	}
+
+	var paths []Path
+	baselineY := hersheyBaseline * scale
+	distance := start
+	for i, glyph := range glyphs {
+		distance += kerning[i]
+		width := (glyph.right - glyph.left) * scale
+		middle := distance + width/2
+		origin := measured.pointAt(middle)
+		tangent := measured.tangentAt(middle, width)
+		normal := Vec2d{-tangent.y, tangent.x}
+		vary := l.vary(i, width)
+		for _, path := range glyph.paths {
+			paths = append(paths, placePath(path, func(point Vec2d) Vec2d {
+				p := vary(Vec2d{(point.x - glyph.left) * scale, point.y * scale})
+				// the glyph's baseline, which vary pivots about, goes on the path
+				return origin.Add(tangent.Multiply(p.x - width/2)).Add(normal.Multiply(p.y - baselineY))
+			}))
+		}
+		distance += l.advance(glyph, scale) + extra
+	}
+	return l.drawing(paths)
+}
+
+func (p Path) reversed() Path {
+	out := make(Path, len(p))
+	for i, point := range p {
+		out[len(p)-1-i] = point
+	}
+	return out
+}
+
+// closed returns true if the path ends where it starts.
+func (p Path) closed() bool {
+	return len(p) > 2 && p[0].Distance(p[len(p)-1]) < pathEpsilon
+}
+
+// measuredPath is a path along with how far along it each of its points
+// is, so that points can be found by distance without walking the whole path.
+type measuredPath struct {
+	path Path
+	// distances[i] is the distance along the path from its start to path[i].
+	distances []float64
+	closed    bool
+}
+
+func newMeasuredPath(p Path) measuredPath {
+	distances := make([]float64, len(p))
+	for i := 1; i < len(p); i++ {
+		distances[i] = distances[i-1] + p[i-1].Distance(p[i])
+	}
+	return measuredPath{path: p, distances: distances, closed: p.closed()}
+}
+
+// length returns the distance along the path from its start to its end.
+func (m measuredPath) length() float64 {
+	if len(m.distances) == 0 {
+		return 0
+	}
+	return m.distances[len(m.distances)-1]
+}
+
+// pointAt returns the point the given distance along the path. Closed paths
+// wrap around, and open ones carry on in a straight line past either end.
+func (m measuredPath) pointAt(distance float64) Vec2d {
+	p := m.path
+	if len(p) < 2 {
+		return p[0]
+	}
+	if length := m.length(); m.closed && length > 0 {
+		distance = math.Mod(distance, length)
+		if distance < 0 {
+			distance += length
+		}
+	}
+	// find the segment that the distance falls in, keeping to the first and
+	// last segments for distances before the start and past the end, and
+	// skipping over any that have no length
+	i := sort.SearchFloat64s(m.distances, distance)
+	if i < 1 {
+		i = 1
+	} else if i > len(p)-1 {
+		i = len(p) - 1
+	}
+	for i < len(p)-1 && m.distances[i] == m.distances[i-1] {
+		i++
+	}
+	for i > 1 && m.distances[i] == m.distances[i-1] {
+		i--
+	}
+	if m.distances[i] == m.distances[i-1] {
+		return p[0]
+	}
+	return p[i-1].LinearInterpolate(p[i], distance-m.distances[i-1])
+}
+
+// tangentAt returns the unit direction of the path at the given distance
+// along it, averaged over the span centered there so that a glyph of that
+// width isn't thrown off by a single short segment.
+func (m measuredPath) tangentAt(distance, span float64) Vec2d {
+	span = math.Max(span, 1e-3)
+	for ; span > pathEpsilon; span /= 2 {
+		d := m.pointAt(distance + span/2).Subtract(m.pointAt(distance - span/2))
+		if d.Magnitude() > pathEpsilon {
+			return d.Normalize()
+		}
+	}
+	return Vec2d{1, 0}
+}
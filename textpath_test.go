@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAlongPath(t *testing.T) {
+	// at a size of one em, inches are font units
+	layout := TextLayout{Font: variationFont, Size: hersheyUnitsPerEm}
+	line := Path{{0, 5}, {100, 5}}
+	tests := []struct {
+		name  string
+		align Alignment
+		opts  PathTextOptions
+		want  Path
+	}{
+		// the foot of the a is on its baseline, which goes on the path
+		{"left", AlignLeft, PathTextOptions{}, Path{{0, -11}, {8, 5}}},
+		{"offset", AlignLeft, PathTextOptions{Offset: 10}, Path{{10, -11}, {18, 5}}},
+		{"center", AlignCenter, PathTextOptions{}, Path{{46, -11}, {54, 5}}},
+		{"right", AlignRight, PathTextOptions{}, Path{{92, -11}, {100, 5}}},
+		{"inside", AlignLeft, PathTextOptions{Inside: true}, Path{{100, 21}, {92, 5}}},
+	}
+	for _, test := range tests {
+		layout.Align = test.align
+		got := layout.AlongPath("a", line, test.opts).penDownPaths()
+		if len(got) != 1 || !pathsNear(got[0], test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAlongPathBaseline(t *testing.T) {
+	layout := TextLayout{Font: FontFutural, Size: 1}
+	paths := layout.AlongPath("I", Path{{0, 2}, {4, 2}}, PathTextOptions{}).penDownPaths()
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, path := range paths {
+		for _, p := range path {
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+		}
+	}
+	// the I stands on the path, reaching up to its cap height
+	if math.Abs(maxY-2) > 1e-9 || math.Abs(minY-(2-21.0/hersheyUnitsPerEm)) > 1e-9 {
+		t.Errorf("I spans y %v to %v, want it to stand on the path at y = 2", minY, maxY)
+	}
+}
+
+func TestAlongPathJustify(t *testing.T) {
+	layout := TextLayout{Font: variationFont, Size: hersheyUnitsPerEm, Align: AlignJustify}
+	paths := layout.AlongPath("aa", Path{{0, 0}, {100, 0}}, PathTextOptions{}).penDownPaths()
+	// the second a is spread to end at the end of the path
+	if len(paths) != 2 || math.Abs(paths[1][1].x-100) > 1e-9 {
+		t.Errorf("got %v, want the text spread across the path", paths)
+	}
+}
+
+func TestMeasuredPath(t *testing.T) {
+	// a square with a repeated point, which has no length
+	measured := newMeasuredPath(Path{{0, 0}, {2, 0}, {2, 0}, {2, 2}, {0, 2}})
+	if measured.length() != 6 {
+		t.Errorf("length = %v, want 6", measured.length())
+	}
+	tests := []struct {
+		distance float64
+		point    Vec2d
+	}{
+		{-1, Vec2d{-1, 0}},
+		{0, Vec2d{0, 0}},
+		{1, Vec2d{1, 0}},
+		{2, Vec2d{2, 0}},
+		{3, Vec2d{2, 1}},
+		{5.5, Vec2d{0.5, 2}},
+		{7, Vec2d{-1, 2}},
+	}
+	for _, test := range tests {
+		if got := measured.pointAt(test.distance); !reflect.DeepEqual(got, test.point) {
+			t.Errorf("pointAt(%v) = %v, want %v", test.distance, got, test.point)
+		}
+	}
+}
+
+// pathsNear reports whether two paths have the same points, to within rounding.
+func pathsNear(a, b Path) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Distance(b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPlotAlongPath(t *testing.T) {
+	const radius, size = 1.5, 0.3
+	center := Vec2d{3, 3}
+	circle := Circle(center, radius).Flatten(DefaultCurveTolerance)
+	layout := TextLayout{Font: FontFutural, Size: size, Align: AlignCenter}
+	d := layout.AlongPath("ROUND AND ROUND", circle, PathTextOptions{})
+	sim := NewSimulator()
+	if err := PlotDrawing(sim, d, PlotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	report := sim.Report()
+	if len(report.Violations) > 0 {
+		t.Errorf("plotting text along a circle broke the limits: %v", report.Violations)
+	}
+	if stats := d.Stats(); report.PenLifts != stats.PenLifts {
+		t.Errorf("the plot lifted the pen %d times, want %d", report.PenLifts, stats.PenLifts)
+	}
+	// the capitals stand on the outside of the circle, no taller than the em
+	for _, path := range sim.paths {
+		if path.penUp {
+			continue
+		}
+		for _, p := range path.Path {
+			p = p.Multiply(1.0 / simRenderScale)
+			if distance := p.Distance(center); distance < radius-0.01 || distance > radius+size {
+				t.Fatalf("the text was drawn at %v, %v from the center of the circle", p, distance)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// validationTolerance is how far a plan may be from exact before the
+// difference is reported, allowing for floating point and search error.
+const validationTolerance = 1e-6
+
+// PlanReport lists the problems found in a plan.
+type PlanReport struct {
+	Violations []string
+}
+
+// OK returns true if no problems were found.
+func (r PlanReport) OK() bool {
+	return len(r.Violations) == 0
+}
+
+func (r PlanReport) String() string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "violations: %d\n", len(r.Violations))
+	for _, v := range r.Violations {
+		fmt.Fprintf(out, "  %s\n", v)
+	}
+	return out.String()
+}
+
+func (r *PlanReport) violate(format string, args ...interface{}) {
+	r.Violations = append(r.Violations, fmt.Sprintf(format, args...))
+}
+
+// ValidatePlan checks that a plan can be sent to the motors: that its blocks
+// join up in position and velocity, stay within the profile's limits, start
+// and end at rest at the ends of the path and cover the same length as it.
+func ValidatePlan(plan Plan, path Path, profile MotionProfile) PlanReport {
+	var r PlanReport
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) <= validationTolerance*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	}
+
+	var pathLength float64
+	for i := 1; i < len(path); i++ {
+		pathLength += path[i-1].Distance(path[i])
+	}
+	if !near(plan.totalLength, pathLength) {
+		r.violate("plan length %.6f doesn't match path length %.6f", plan.totalLength, pathLength)
+	}
+	if len(plan.blocks) == 0 {
+		return r
+	}
+
+	var totalTime, totalLength float64
+	for i, b := range plan.blocks {
+		if math.IsNaN(b.t) || math.IsInf(b.t, 0) || b.t <= 0 {
+			r.violate("block %d: duration %g isn't positive", i, b.t)
+			continue
+		}
+		totalTime += b.t
+		totalLength += b.length()
+		entry, exit := b.instant(0), b.instant(b.t)
+
+		// the kinematics have to carry the block exactly from its start to its end
+		travelled := b.velocity*b.t + b.accel*b.t*b.t/2 + b.jerk*b.t*b.t*b.t/6
+		if !near(travelled, b.length()) {
+			r.violate("block %d: motion covers %.6f but the block is %.6f long", i, travelled, b.length())
+		}
+
+		// velocity is quadratic over a block with jerk, so it can peak part way through
+		velocities := []float64{entry.velocity, exit.velocity}
+		if b.jerk != 0 {
+			if t := -b.accel / b.jerk; t > 0 && t < b.t {
+				velocities = append(velocities, b.instant(t).velocity)
+			}
+		}
+		for _, v := range velocities {
+			if v < -validationTolerance {
+				r.violate("block %d: velocity %.6f is negative", i, v)
+			}
+			if v > profile.MaxVelocity+validationTolerance {
+				r.violate("block %d: velocity %.6f exceeds max of %.6f", i, v, profile.MaxVelocity)
+			}
+		}
+		for _, a := range []float64{entry.accel, exit.accel} {
+			if math.Abs(a) > profile.Accel+validationTolerance {
+				r.violate("block %d: acceleration %.6f exceeds max of %.6f", i, a, profile.Accel)
+			}
+		}
+		if math.Abs(b.jerk) > profile.Jerk+validationTolerance {
+			r.violate("block %d: jerk %.6f exceeds max of %.6f", i, b.jerk, profile.Jerk)
+		}
+
+		if i == 0 {
+			continue
+		}
+		prev := plan.blocks[i-1]
+		prevExit := prev.instant(prev.t)
+		if d := prev.end.Distance(b.start); d > validationTolerance {
+			r.violate("block %d: starts %.6f away from where block %d ends", i, d, i-1)
+		}
+		if !near(prevExit.velocity, entry.velocity) {
+			r.violate("block %d: starts at velocity %.6f but block %d ends at %.6f", i, entry.velocity, i-1, prevExit.velocity)
+		}
+		// S-curves only work if the acceleration never jumps
+		if profile.Jerk > 0 && !near(prevExit.accel, entry.accel) {
+			r.violate("block %d: starts at acceleration %.6f but block %d ends at %.6f", i, entry.accel, i-1, prevExit.accel)
+		}
+	}
+
+	first, last := plan.blocks[0], plan.blocks[len(plan.blocks)-1]
+	if d := first.start.Distance(path[0]); d > validationTolerance {
+		r.violate("plan starts %.6f away from the start of the path", d)
+	}
+	if d := last.end.Distance(path[len(path)-1]); d > validationTolerance {
+		r.violate("plan ends %.6f away from the end of the path", d)
+	}
+	if v := first.velocity; math.Abs(v) > validationTolerance {
+		r.violate("plan starts at velocity %.6f instead of at rest", v)
+	}
+	if v := last.instant(last.t).velocity; math.Abs(v) > validationTolerance {
+		r.violate("plan ends at velocity %.6f instead of at rest", v)
+	}
+	if !near(totalTime, plan.totalTime) {
+		r.violate("blocks take %.6fs but the plan's total time is %.6fs", totalTime, plan.totalTime)
+	}
+	if !near(totalLength, plan.totalLength) {
+		r.violate("blocks cover %.6f but the plan's total length is %.6f", totalLength, plan.totalLength)
+	}
+	return r
+}
+
+// ValidatePlans checks the plan for each path of the drawing with ValidatePlan.
+func (d Drawing) ValidatePlans(profiles MotionProfiles, plans []Plan) PlanReport {
+	var r PlanReport
+	for i, plan := range plans {
+		path := d.paths[i]
+		for _, v := range ValidatePlan(plan, path.Path, profiles.forPath(path)).Violations {
+			r.violate("path %d: %s", i, v)
+		}
+	}
+	return r
+}
@@ -0,0 +1,158 @@
+package main
+
+import "math"
+
+// TextVariation makes text look hand-written by moving, turning and
+// resizing each glyph a little, and optionally roughening its strokes and
+// swapping it for the same character from another font. Every amount is
+// the most that a glyph can vary by either way, and the variation is drawn
+// from the seed and the position of each character in the text, so the
+// same text comes out the same every time. Lengths are fractions of the
+// text size.
+type TextVariation struct {
+	Seed int64
+	// Position moves each glyph along the line.
+	Position float64
+	// Baseline moves each glyph above or below the line.
+	Baseline float64
+	// Rotation turns each glyph about the middle of its baseline, in radians.
+	Rotation float64
+	// Scale grows or shrinks each glyph about the middle of its baseline, as a fraction of its size.
+	Scale float64
+	// Noise moves each point of each glyph on its own.
+	Noise float64
+	// Alternates are fonts that each character may be drawn from instead,
+	// choosing evenly between the layout's font and those alternates that have it.
+	Alternates []Font
+}
+
+// DefaultHandwriting is a variation that's noticeable without making text hard to read.
+var DefaultHandwriting = TextVariation{
+	Position: 0.015,
+	Baseline: 0.02,
+	Rotation: 0.05,
+	Scale:    0.04,
+	Noise:    0.003,
+}
+
+// Scaled returns the variation with every amount multiplied by the factor.
+func (v TextVariation) Scaled(factor float64) TextVariation {
+	v.Position *= factor
+	v.Baseline *= factor
+	v.Rotation *= factor
+	v.Scale *= factor
+	v.Noise *= factor
+	return v
+}
+
+func (v TextVariation) enabled() bool {
+	return v.Position != 0 || v.Baseline != 0 || v.Rotation != 0 || v.Scale != 0 || v.Noise != 0
+}
+
+// The variation of each character is drawn from separate streams, so that
+// turning one kind of variation on or off doesn't change the others.
+const (
+	alternateStream = iota + 1
+	jitterStream
+)
+
+// variationRand is a small splitmix64 generator. Unlike math/rand it's
+// cheap to make one for every glyph.
+type variationRand uint64
+
+func newVariationRand(seed int64, index int, stream uint64) *variationRand {
+	r := variationRand(uint64(seed)*0x9e3779b97f4a7c15 ^ uint64(index)*0xbf58476d1ce4e5b9 ^ stream*0x94d049bb133111eb)
+	r.next()
+	return &r
+}
+
+func (r *variationRand) next() uint64 {
+	*r += 0x9e3779b97f4a7c15
+	z := uint64(*r)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// uniform returns a number in [-1, 1).
+func (r *variationRand) uniform() float64 {
+	return float64(r.next()>>11)/(1<<52) - 1
+}
+
+// glyphAt returns the glyph for the character at the given index in the
+// text, which may come from one of the variation's alternate fonts. It also
+// returns which font that was, as 0 for the layout's font and i+1 for
+// Alternates[i], so that the glyph is kerned by its own font.
+func (l TextLayout) glyphAt(ch rune, index int) (Glyph, int) {
+	if alternates := l.Variation.Alternates; len(alternates) > 0 {
+		var candidates []Glyph
+		var sources []int
+		if glyph, ok := l.Font.glyph(ch); ok {
+			candidates = append(candidates, glyph)
+			sources = append(sources, 0)
+		}
+		for i, font := range alternates {
+			if glyph, ok := font.glyph(ch); ok {
+				candidates = append(candidates, glyph)
+				sources = append(sources, i+1)
+			}
+		}
+		if len(candidates) > 0 {
+			r := newVariationRand(l.Variation.Seed, index, alternateStream)
+			choice := r.next() % uint64(len(candidates))
+			return candidates[choice], sources[choice]
+		}
+	}
+	return l.glyph(ch), 0
+}
+
+// kern returns the adjustment to the advance from one character to the
+// next, given which fonts glyphAt drew them from. Characters from different
+// fonts aren't kerned, since neither font has kerning for the pair.
+func (l TextLayout) kern(a, b rune, sourceA, sourceB int) float64 {
+	if sourceA != sourceB {
+		return 0
+	}
+	if sourceB == 0 {
+		return l.Font.kern(a, b)
+	}
+	return l.Variation.Alternates[sourceB-1].kern(a, b)
+}
+
+// vary returns the transform that jitters the glyph for the character at the
+// given index. Points are given in inches from the left of the glyph's
+// advance, which is width wide, and from the font's origin.
+func (l TextLayout) vary(index int, width float64) func(Vec2d) Vec2d {
+	v := l.Variation
+	if !v.enabled() {
+		return func(p Vec2d) Vec2d { return p }
+	}
+	r := newVariationRand(v.Seed, index, jitterStream)
+	offset := Vec2d{r.uniform() * v.Position * l.Size, r.uniform() * v.Baseline * l.Size}
+	sin, cos := math.Sincos(r.uniform() * v.Rotation)
+	factor := 1 + r.uniform()*v.Scale
+	pivot := Vec2d{width / 2, hersheyBaseline * l.Size / hersheyUnitsPerEm}
+	noise := v.Noise * l.Size
+	return func(p Vec2d) Vec2d {
+		d := p.Subtract(pivot).Multiply(factor)
+		p = pivot.Add(Vec2d{d.x*cos - d.y*sin, d.x*sin + d.y*cos}).Add(offset)
+		if noise > 0 {
+			p = p.Add(Vec2d{r.uniform() * noise, r.uniform() * noise})
+		}
+		return p
+	}
+}
+
+// placePath moves each point of a glyph's path into place. Noise moves the
+// ends of a closed path apart, so they're joined again, which keeps outline
+// glyphs closed for Hatch to fill.
+func placePath(path Path, place func(Vec2d) Vec2d) Path {
+	placed := make(Path, 0, len(path))
+	for _, point := range path {
+		placed = append(placed, place(point))
+	}
+	if path.closed() {
+		placed[len(placed)-1] = placed[0]
+	}
+	return placed
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// variationFont has an "o" made of a square with a square counter, and a
+// kerned "a".
+var variationFont = Font{
+	runes: map[rune]Glyph{
+		' ': {left: -5, right: 5},
+		'o': {left: -6, right: 6, paths: []Path{square(-5, -5, 10), square(-2, -2, 4)}},
+		'a': {left: -4, right: 4, paths: []Path{{{-4, -7}, {4, 9}}}},
+	},
+	kerning: map[[2]rune]float64{{'a', 'a'}: -2},
+}
+
+func TestVariationIsDeterministic(t *testing.T) {
+	layout := TextLayout{Font: variationFont, Size: 1, Variation: DefaultHandwriting.Scaled(5)}
+	layout.Variation.Seed = 1
+	first := layout.Layout("oao ao\noa").penDownPaths()
+	if again := layout.Layout("oao ao\noa").penDownPaths(); !reflect.DeepEqual(first, again) {
+		t.Error("the same text with the same seed came out differently")
+	}
+	plain := layout
+	plain.Variation = TextVariation{}
+	if reflect.DeepEqual(first, plain.Layout("oao ao\noa").penDownPaths()) {
+		t.Error("the variation didn't change anything")
+	}
+	layout.Variation.Seed = 2
+	if reflect.DeepEqual(first, layout.Layout("oao ao\noa").penDownPaths()) {
+		t.Error("a different seed came out the same")
+	}
+}
+
+func TestVariationKeepsClosedPathsClosed(t *testing.T) {
+	layout := TextLayout{Font: variationFont, Size: 1, Hatch: 0.01, Variation: TextVariation{Seed: 3, Noise: 0.05}}
+	circle := Path{}
+	for i := 0; i <= 64; i++ {
+		angle := 2 * math.Pi * float64(i) / 64
+		circle = append(circle, Vec2d{3 * math.Cos(angle), 3 * math.Sin(angle)})
+	}
+	drawings := map[string]Drawing{
+		"Layout":    layout.Layout("o"),
+		"AlongPath": layout.AlongPath("o", circle, PathTextOptions{}),
+	}
+	for name, d := range drawings {
+		paths := d.penDownPaths()
+		// the two outlines of the o come first, then the hatching inside them
+		if len(paths) <= 2 {
+			t.Errorf("%s: the o wasn't hatched", name)
+			continue
+		}
+		for _, path := range paths[:2] {
+			if path[0] != path[len(path)-1] {
+				t.Errorf("%s: the noise opened up %v", name, path)
+			}
+		}
+	}
+}
+
+func TestAlternatesUseTheirOwnKerning(t *testing.T) {
+	alternate := Font{
+		runes:   map[rune]Glyph{'a': variationFont.runes['a']},
+		kerning: map[[2]rune]float64{{'a', 'a'}: -6},
+	}
+	// only the alternate has a "b", so "ab" mixes fonts
+	alternate.runes['b'] = variationFont.runes['a']
+	layout := TextLayout{Font: Font{runes: map[rune]Glyph{' ': {left: -5, right: 5}}}, Size: hersheyUnitsPerEm}
+	layout.Variation.Alternates = []Font{alternate}
+	if advance := layout.Measure("aa").Advance; advance != 10 {
+		t.Errorf("aa from the alternate font measures %v, want 10", advance)
+	}
+
+	layout.Font = variationFont
+	if kern := layout.kern('a', 'b', 0, 1); kern != 0 {
+		t.Errorf("characters from different fonts were kerned by %v", kern)
+	}
+	if kern := layout.kern('a', 'a', 1, 1); kern != -6 {
+		t.Errorf("characters from the alternate font were kerned by %v, want -6", kern)
+	}
+	if kern := layout.kern('a', 'a', 0, 0); kern != -2 {
+		t.Errorf("characters from the layout's font were kerned by %v, want -2", kern)
+	}
+}